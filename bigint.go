@@ -0,0 +1,85 @@
+package typedbuffer
+
+import (
+	"math"
+	"math/big"
+)
+
+//
+// Encode big.Int value.
+// Values in the int64 range are encoded (and decoded) as int64, so that they sort together
+//
+func EncodeBigInt(x *big.Int) []byte {
+	return appendBigInt(nil, x)
+}
+
+//
+// Append encoded big.Int value to dst
+//
+func appendBigInt(dst []byte, x *big.Int) []byte {
+	if x.IsInt64() {
+		return AppendInt64(dst, x.Int64())
+	}
+
+	m := x.Bytes()
+	l := uint32(len(m))
+
+	if x.Sign() > 0 {
+		dst = append(dst, BB_BIG_POSITIVE, byte(l>>24), byte(l>>16), byte(l>>8), byte(l>>0))
+		return append(dst, m...)
+	}
+
+	dst = append(dst, BB_BIG_NEGATIVE, ^byte(l>>24), ^byte(l>>16), ^byte(l>>8), ^byte(l>>0))
+	for _, c := range m {
+		dst = append(dst, ^c)
+	}
+
+	return dst
+}
+
+//
+// Return the magnitude length of a big integer from its length header
+//
+func bigLen(h0, h1, h2, h3 byte) (int, error) {
+	n := uint64(h0)<<24 + uint64(h1)<<16 + uint64(h2)<<8 + uint64(h3)
+	if n > math.MaxInt {
+		return 0, CorruptedBufferError
+	}
+
+	return int(n), nil
+}
+
+//
+// Decode big integer with tag k (b is the buffer after the tag). Returns decoded value and remaining buffer
+//
+func decodeBigInt(k byte, b []byte) (interface{}, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, CorruptedBufferError
+	}
+
+	n, err := payloadLen(k, b[0:4])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b = b[4:]
+	if len(b) < n {
+		return nil, nil, CorruptedBufferError
+	}
+
+	x := new(big.Int)
+
+	if k == BB_BIG_POSITIVE {
+		x.SetBytes(b[0:n])
+	} else {
+		m := make([]byte, n)
+		for i := range m {
+			m[i] = ^b[i]
+		}
+
+		x.SetBytes(m)
+		x.Neg(x)
+	}
+
+	return x, b[n:], nil
+}
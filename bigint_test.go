@@ -0,0 +1,77 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestBigInt(t *testing.T) {
+	maxUint := new(big.Int).SetUint64(math.MaxUint64)
+	minInt := big.NewInt(math.MinInt64)
+
+	values := []*big.Int{
+		new(big.Int).Lsh(big.NewInt(-1), 200),
+		new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(-1), 64), big.NewInt(1)),
+		new(big.Int).Lsh(big.NewInt(-1), 64),
+		new(big.Int).Sub(minInt, big.NewInt(1)),
+		minInt,
+		big.NewInt(-1000),
+		big.NewInt(0),
+		big.NewInt(1000),
+		big.NewInt(math.MaxInt64),
+		new(big.Int).Add(big.NewInt(math.MaxInt64), big.NewInt(1)),
+		maxUint,
+		new(big.Int).Add(maxUint, big.NewInt(1)),
+		new(big.Int).Lsh(big.NewInt(1), 200),
+	}
+
+	var prev []byte
+
+	for _, x := range values {
+		b := EncodeBigInt(x)
+
+		if l, _ := EncodedLen(x); l != len(b) {
+			t.Error(x, "expected length", len(b), "got", l)
+		}
+
+		if prev != nil && bytes.Compare(prev, b) != -1 {
+			t.Error(prev, "should be less than", b)
+		}
+
+		v, next, err := Decode(append(b, One...))
+		if err != nil {
+			t.Fatal(x, err)
+		}
+
+		if !bytes.Equal(next, One) {
+			t.Error(x, "unexpected remaining buffer", next)
+		}
+
+		switch d := v.(type) {
+		case int64:
+			if !x.IsInt64() || d != x.Int64() {
+				t.Error("expected", x, "got", d)
+			}
+
+		case *big.Int:
+			if x.IsInt64() || d.Cmp(x) != 0 {
+				t.Error("expected", x, "got", d)
+			}
+
+		default:
+			t.Errorf("%v: unexpected type %T", x, v)
+		}
+
+		if next, err := SkipValue(append(b, One...)); err != nil || !bytes.Equal(next, One) {
+			t.Error(x, "unexpected skip", next, err)
+		}
+
+		prev = b
+	}
+
+	if !bytes.Equal(MustEncode((*big.Int)(nil)), NilFirst) {
+		t.Error("nil big.Int should be encoded as nil")
+	}
+}
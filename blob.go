@@ -0,0 +1,173 @@
+package typedbuffer
+
+import (
+	"fmt"
+	"io"
+)
+
+//
+// A BytesWriter encodes a slice of bytes of unknown length to an io.Writer, as it is written,
+// so that large blobs don't need to be kept in memory.
+// The value is encoded as ordered bytes (see EncodeBytesOrdered), so it can also be decoded with Decode
+//
+type BytesWriter struct {
+	w       io.Writer
+	buf     []byte
+	started bool
+	closed  bool
+}
+
+//
+// Create a new BytesWriter that writes an encoded slice of bytes to w
+//
+func NewBytesWriter(w io.Writer) *BytesWriter {
+	return &BytesWriter{w: w}
+}
+
+//
+// Write p as the next part of the encoded slice of bytes
+//
+func (bw *BytesWriter) Write(p []byte) (int, error) {
+	if bw.closed {
+		return 0, WriterClosedError
+	}
+
+	bw.buf = bw.buf[:0]
+
+	if !bw.started {
+		bw.buf = append(bw.buf, BB_BYTES_ORDERED)
+		bw.started = true
+	}
+
+	for _, c := range p {
+		if c == BB_ORDERED_ESC {
+			bw.buf = append(bw.buf, BB_ORDERED_ESC, BB_ORDERED_00)
+		} else {
+			bw.buf = append(bw.buf, c)
+		}
+	}
+
+	if _, err := bw.w.Write(bw.buf); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+//
+// Terminate the encoded slice of bytes. It doesn't close the underlying writer
+//
+func (bw *BytesWriter) Close() error {
+	if bw.closed {
+		return WriterClosedError
+	}
+
+	b := []byte{BB_ORDERED_ESC, BB_ORDERED_END}
+	if !bw.started {
+		b = []byte{BB_BYTES_ORDERED, BB_ORDERED_ESC, BB_ORDERED_END}
+	}
+
+	bw.closed = true
+
+	_, err := bw.w.Write(b)
+	return err
+}
+
+//
+// Encode a slice of n bytes read from r to w, without reading it in memory first:
+// the length header is written first, then the content is copied from r.
+// Returns io.ErrUnexpectedEOF if r has less than n bytes (w then contains a truncated value)
+// and BytesTooLongError if n is too large to be encoded
+//
+func EncodeBytesFrom(w io.Writer, r io.Reader, n int) error {
+	if n < 0 {
+		return fmt.Errorf("%w: negative length %d", NoEncoding, n)
+	}
+
+	if err := checkBytesLen(n); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(appendBytesHeader(nil, n)); err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(w, r, int64(n)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+//
+// Start decoding a slice of bytes written with a BytesWriter, returning a reader
+// for its content. The reader returns io.EOF at the end of the slice, after which
+// the Decoder can be used to decode the following values.
+// Returns CorruptedBufferError if the next value is not a streamed slice of bytes
+//
+func (d *Decoder) DecodeBytesReader() (io.Reader, error) {
+	var tag [1]byte
+
+	if _, err := io.ReadFull(d.r, tag[:]); err != nil {
+		return nil, err
+	}
+
+	if tag[0] != BB_BYTES_ORDERED {
+		return nil, CorruptedBufferError
+	}
+
+	return &bytesReader{d: d}, nil
+}
+
+//
+// bytesReader reads the content of a streamed slice of bytes, removing the escapes
+//
+type bytesReader struct {
+	d   *Decoder
+	err error
+}
+
+func (br *bytesReader) Read(p []byte) (int, error) {
+	n := 0
+
+	for n < len(p) && br.err == nil {
+		c, err := br.d.readByte()
+		if err != nil {
+			br.err = err
+			break
+		}
+
+		if c != BB_ORDERED_ESC {
+			p[n] = c
+			n++
+			continue
+		}
+
+		if c, err = br.d.readByte(); err != nil {
+			br.err = err
+			break
+		}
+
+		switch c {
+		case BB_ORDERED_00:
+			p[n] = 0
+			n++
+
+		case BB_ORDERED_END:
+			br.err = io.EOF
+
+		default:
+			br.err = CorruptedBufferError
+		}
+	}
+
+	if n > 0 {
+		return n, nil
+	}
+
+	return 0, br.err
+}
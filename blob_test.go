@@ -0,0 +1,151 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestBytesWriter(t *testing.T) {
+	blob := make([]byte, 1<<20)
+	rand.New(rand.NewSource(42)).Read(blob)
+
+	var buf bytes.Buffer
+	buf.Write(EncodeInt64(1))
+
+	bw := NewBytesWriter(&buf)
+	for p := blob; len(p) > 0; {
+		n := 1000
+		if n > len(p) {
+			n = len(p)
+		}
+
+		if w, err := bw.Write(p[:n]); err != nil || w != n {
+			t.Fatal("expected", n, "got", w, err)
+		}
+
+		p = p[n:]
+	}
+
+	if err := bw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Write(EncodeString("after"))
+
+	encoded := append([]byte{}, buf.Bytes()...)
+
+	dec := NewDecoder(&buf)
+	if v, err := dec.Decode(); err != nil || v != int64(1) {
+		t.Fatal("expected 1, got", v, err)
+	}
+
+	r, err := dec.DecodeBytesReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(content, blob) {
+		t.Error("streamed content doesn't match")
+	}
+
+	if v, err := dec.Decode(); err != nil || string(v.([]byte)) != "after" {
+		t.Error("expected after, got", v, err)
+	}
+
+	// the streamed value can also be decoded as a whole
+	values, err := DecodeAll(false, encoded)
+	if err != nil || len(values) != 3 || !bytes.Equal(values[1].([]byte), blob) {
+		t.Error("unexpected", len(values), err)
+	}
+
+	if _, err := bw.Write([]byte("x")); err != WriterClosedError {
+		t.Error("expected", WriterClosedError, "got", err)
+	}
+}
+
+func TestBytesWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+
+	bw := NewBytesWriter(&buf)
+	if err := bw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := EncodeBytesOrdered(nil); !bytes.Equal(buf.Bytes(), expected) {
+		t.Error("expected", expected, "got", buf.Bytes())
+	}
+}
+
+func TestDecodeBytesReaderErrors(t *testing.T) {
+	dec := NewDecoder(bytes.NewReader(EncodeString("hello")))
+	if _, err := dec.DecodeBytesReader(); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	b := EncodeBytesOrdered([]byte("hello"))
+
+	dec = NewDecoder(bytes.NewReader(b[:len(b)-1]))
+	r, err := dec.DecodeBytesReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := io.ReadAll(r); err != io.ErrUnexpectedEOF {
+		t.Error("expected", io.ErrUnexpectedEOF, "got", err)
+	}
+}
+
+func TestEncodeBytesFrom(t *testing.T) {
+	for _, l := range []int{0, 1, 60, 61, 316, 317, 70000} {
+		content := strings.Repeat("x", l)
+
+		var buf bytes.Buffer
+		if err := EncodeBytesFrom(&buf, strings.NewReader(content), l); err != nil {
+			t.Fatal(l, err)
+		}
+
+		if expected := EncodeString(content); !bytes.Equal(buf.Bytes(), expected) {
+			t.Error(l, "unexpected encoding", len(buf.Bytes()), "bytes, expected", len(expected))
+		}
+	}
+
+	// only n bytes are read
+	var buf bytes.Buffer
+	r := strings.NewReader("hello world")
+
+	if err := EncodeBytesFrom(&buf, r, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, next, err := DecodeString(buf.Bytes()); err != nil || s != "hello" || len(next) != 0 {
+		t.Error("expected hello, got", s, next, err)
+	}
+
+	if r.Len() != 6 {
+		t.Error("expected 6 unread bytes, got", r.Len())
+	}
+
+	if err := EncodeBytesFrom(&buf, strings.NewReader("abc"), 4); err != io.ErrUnexpectedEOF {
+		t.Error("expected", io.ErrUnexpectedEOF, "got", err)
+	}
+
+	if err := EncodeBytesFrom(&buf, strings.NewReader(""), -1); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	defer func(l int64) { maxBytesLen = l }(maxBytesLen)
+	maxBytesLen = 10
+
+	if err := EncodeBytesFrom(&buf, strings.NewReader(""), 11); err != BytesTooLongError {
+		t.Error("expected", BytesTooLongError, "got", err)
+	}
+}
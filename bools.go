@@ -0,0 +1,130 @@
+package typedbuffer
+
+//
+// Encode an array of bools packed as a bitset, prefixed by the number of values.
+// Returns ValueOutOfRangeError if the array has more than 2^32-1 values
+//
+func EncodeBools(xs []bool) ([]byte, error) {
+	if err := checkBoolsLen(len(xs)); err != nil {
+		return nil, err
+	}
+
+	return appendBools(make([]byte, 0, boolsLen(len(xs))), xs), nil
+}
+
+//
+// Return ValueOutOfRangeError if an array of n bools is too long to be encoded
+//
+func checkBoolsLen(n int) error {
+	if uint64(n) > 0xffffffff {
+		return ValueOutOfRangeError
+	}
+
+	return nil
+}
+
+//
+// Return the length of an encoded array of n bools
+//
+func boolsLen(n int) int {
+	return 5 + (n+7)/8
+}
+
+//
+// Append encoded array of bools to dst (the length must have been checked with checkBoolsLen)
+//
+func appendBools(b []byte, xs []bool) []byte {
+	n := len(xs)
+
+	b = append(b, BB_BOOLEAN_ARRAY, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+
+	for i := 0; i < n; i += 8 {
+		var c byte
+
+		for j := 0; j < 8 && i+j < n; j++ {
+			if xs[i+j] {
+				c |= 0x80 >> uint(j)
+			}
+		}
+
+		b = append(b, c)
+	}
+
+	return b
+}
+
+//
+// Decode first value in typed buffer as an array of bools encoded with EncodeBools.
+// Returns decoded values and remaining buffer
+//
+func DecodeBools(b []byte) ([]bool, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, EmptyBufferError
+	}
+
+	if b[0] != BB_BOOLEAN_ARRAY {
+		return nil, nil, CorruptedBufferError
+	}
+
+	return decodeBools(b[1:])
+}
+
+//
+// Decode the count and bits of an array of bools (following the tag)
+//
+func decodeBools(b []byte) ([]bool, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, CorruptedBufferError
+	}
+
+	n := uint64(b[0])<<24 + uint64(b[1])<<16 + uint64(b[2])<<8 + uint64(b[3])
+	b = b[4:]
+
+	// check the count against the buffer before converting it, int may be 32 bits
+	if n > uint64(len(b))*8 {
+		return nil, nil, CorruptedBufferError
+	}
+
+	l := int((n + 7) / 8)
+
+	xs := make([]bool, n)
+	for i := range xs {
+		xs[i] = b[i/8]&(0x80>>uint(i%8)) != 0
+	}
+
+	return xs, b[l:], nil
+}
+
+//
+// Encode nullable bool (three-valued logic): a nil pointer (unknown) is encoded as nil first or nil last,
+// according to nilFirst, so that unknown sorts before false (or after true)
+//
+func EncodeNullBool(b *bool, nilFirst bool) []byte {
+	if b == nil {
+		return AppendNil(nil, nilFirst)
+	}
+
+	return AppendBool(nil, *b)
+}
+
+//
+// Decode first value in typed buffer as a nullable bool. Returns nil for a nil value and the remaining buffer,
+// or CorruptedBufferError if the value is not a bool or nil
+//
+func DecodeNullBool(b []byte) (*bool, []byte, error) {
+	v, next, err := Decode(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch t := v.(type) {
+	case nil:
+		return nil, next, nil
+
+	case bool:
+		return &t, next, nil
+
+	default:
+		return nil, nil, CorruptedBufferError
+	}
+}
@@ -0,0 +1,169 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func MustEncodeBools(xs []bool) []byte {
+	b, err := EncodeBools(xs)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestEncodeBools(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 8, 9, 16, 100} {
+		xs := make([]bool, n)
+		for i := range xs {
+			xs[i] = i%3 == 0
+		}
+
+		b, err := EncodeBools(xs)
+		if err != nil {
+			t.Fatal(n, err)
+		}
+		if len(b) != 5+(n+7)/8 {
+			t.Error(n, "unexpected length", len(b))
+		}
+
+		d, next, err := DecodeBools(append(b, One...))
+		if err != nil || !bytes.Equal(next, One) {
+			t.Fatal(n, "unexpected", next, err)
+		}
+
+		if len(d) != n {
+			t.Fatal(n, "expected", xs, "got", d)
+		}
+
+		for i := range xs {
+			if d[i] != xs[i] {
+				t.Error(n, i, "expected", xs[i], "got", d[i])
+			}
+		}
+
+		if v, err := DecodeOne(b); err != nil || len(v.([]bool)) != n {
+			t.Error(n, "unexpected", v, err)
+		}
+
+		if l, err := ValueLen(append(b, One...)); err != nil || l != len(b) {
+			t.Error(n, "expected length", len(b), "got", l, err)
+		}
+
+		if eb, err := Encode(xs); err != nil || !bytes.Equal(eb, b) {
+			t.Error(n, "expected Encode to match EncodeBools", eb, err)
+		}
+
+		if l, err := EncodedLen(xs); err != nil || l != len(b) {
+			t.Error(n, "expected encoded length", len(b), "got", l, err)
+		}
+	}
+
+	b := MustEncodeBools([]bool{true, false, true})
+	if !bytes.Equal(b, []byte{BB_BOOLEAN_ARRAY, 0, 0, 0, 3, 0xA0}) {
+		t.Error("unexpected encoding", b)
+	}
+
+	if _, _, err := DecodeBools(b[:5]); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := DecodeBools(True); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	// crafted count larger than the payload (negative as an int on 32-bit)
+	crafted := []byte{BB_BOOLEAN_ARRAY, 0xFF, 0xFF, 0xFF, 0xFF}
+
+	if _, _, err := DecodeBools(crafted); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := Decode(crafted); err == nil {
+		t.Error("expected error decoding", crafted)
+	}
+}
+
+func TestEncodeBoolsRoundTrip(t *testing.T) {
+	values := []interface{}{int64(1), []bool{true, false, true}, "x"}
+
+	b, err := Encode(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := DecodeAll(true, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rb, err := Encode(res...)
+	if err != nil || !bytes.Equal(rb, b) {
+		t.Error("expected", b, "got", rb, err)
+	}
+}
+
+func TestCompareBools(t *testing.T) {
+	tests := []CompareItem{
+		{MustEncodeBools(nil), MustEncodeBools([]bool{false})},
+		{MustEncodeBools([]bool{false}), MustEncodeBools([]bool{true})},
+		{MustEncodeBools([]bool{true, false}), MustEncodeBools([]bool{true, true})},
+		{MustEncodeBools([]bool{true}), MustEncodeBools([]bool{true, false})},
+	}
+
+	for _, tt := range tests {
+		if Compare(tt.min, tt.max) != -1 || Compare(tt.max, tt.min) != 1 {
+			t.Error(tt.min, "should compare less than", tt.max)
+		}
+	}
+}
+
+func TestNullBool(t *testing.T) {
+	f, tr := false, true
+
+	// unknown (nil first) < false < true < unknown (nil last)
+	ordered := [][]byte{
+		EncodeNullBool(nil, true),
+		EncodeNullBool(&f, true),
+		EncodeNullBool(&tr, false),
+		EncodeNullBool(nil, false),
+	}
+
+	expected := []*bool{nil, &f, &tr, nil}
+
+	for i, b := range ordered {
+		if len(b) != 1 {
+			t.Error("expected 1 byte, got", b)
+		}
+
+		if i > 0 && (bytes.Compare(ordered[i-1], b) != -1 || Compare(ordered[i-1], b) != -1) {
+			t.Error(ordered[i-1], "should be less than", b)
+		}
+
+		v, next, err := DecodeNullBool(append(b, One...))
+		if err != nil || !bytes.Equal(next, One) {
+			t.Fatal("unexpected", next, err)
+		}
+
+		switch {
+		case expected[i] == nil && v != nil:
+			t.Error("expected nil, got", *v)
+
+		case expected[i] != nil && (v == nil || *v != *expected[i]):
+			t.Error("expected", *expected[i], "got", v)
+		}
+	}
+
+	if !bytes.Equal(EncodeNullBool(&tr, true), EncodeBool(true)) {
+		t.Error("expected the same encoding as EncodeBool")
+	}
+
+	if _, _, err := DecodeNullBool(EncodeInt64(1)); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := DecodeNullBool(nil); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+}
@@ -0,0 +1,44 @@
+package typedbuffer
+
+//
+// A Builder encodes values into a reusable buffer.
+// The zero value is an empty Builder ready to use.
+//
+type Builder struct {
+	buf []byte
+}
+
+//
+// Encode one or more values, appending them to the buffer
+// (nil values are encoded as nil first)
+//
+func (b *Builder) Add(values ...interface{}) error {
+	buf, err := appendValues(b.buf, true, values)
+	if err != nil {
+		return err
+	}
+
+	b.buf = buf
+	return nil
+}
+
+//
+// Return the encoded values. The returned slice is only valid until the next call to Reset
+//
+func (b *Builder) Bytes() []byte {
+	return b.buf
+}
+
+//
+// Return the number of bytes in the buffer
+//
+func (b *Builder) Len() int {
+	return len(b.buf)
+}
+
+//
+// Empty the buffer, keeping the allocated space for reuse
+//
+func (b *Builder) Reset() {
+	b.buf = b.buf[:0]
+}
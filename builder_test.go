@@ -0,0 +1,70 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	var b Builder
+
+	if err := b.Add(10, "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Add(-100000, false, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := MustEncode(10, "hello", -100000, false, nil); !bytes.Equal(b.Bytes(), expected) {
+		t.Error("expected", expected, "got", b.Bytes())
+	}
+
+	if err := b.Add(1, struct{}{}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if b.Len() != len(MustEncode(10, "hello", -100000, false, nil)) {
+		t.Error("failed Add should not change the buffer", b.Bytes())
+	}
+
+	c := cap(b.Bytes())
+	b.Reset()
+
+	if b.Len() != 0 || cap(b.Bytes()) != c {
+		t.Error("expected empty buffer with capacity", c, "got", b.Len(), cap(b.Bytes()))
+	}
+}
+
+func TestBuilderAllocs(t *testing.T) {
+	var b Builder
+
+	allocs := testing.AllocsPerRun(100, func() {
+		b.Reset()
+		b.Add(1, "hello, world", uint64(1000), 100000, true, nil)
+	})
+
+	if allocs != 0 {
+		t.Error("expected no allocations, got", allocs)
+	}
+}
+
+func BenchmarkBuilder(b *testing.B) {
+	var kb Builder
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		kb.Reset()
+		kb.Add(1, "hello, world", uint64(1000), 100000, true, nil)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		Encode(1, "hello, world", uint64(1000), 100000, true, nil)
+	}
+}
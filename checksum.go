@@ -0,0 +1,42 @@
+package typedbuffer
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+//
+// Length of the checksum appended by EncodeChecksummed
+//
+const CHECKSUM_LEN = 4
+
+//
+// Encode values as Encode, followed by the CRC32 (IEEE) of the encoded values, as 4 bytes big endian.
+// Since the checksum is a suffix, the ordering of buffers stripped of their checksum is unaffected
+//
+func EncodeChecksummed(values ...interface{}) ([]byte, error) {
+	b, err := Encode(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b)), nil
+}
+
+//
+// Verify the checksum of a buffer encoded with EncodeChecksummed and decode all values.
+// Returns ChecksumMismatchError if the checksum doesn't match
+// and CorruptedBufferError if the buffer is too short to contain a checksum
+//
+func DecodeChecksummed(b []byte) ([]interface{}, error) {
+	if len(b) < CHECKSUM_LEN {
+		return nil, CorruptedBufferError
+	}
+
+	n := len(b) - CHECKSUM_LEN
+	if crc32.ChecksumIEEE(b[:n]) != binary.BigEndian.Uint32(b[n:]) {
+		return nil, ChecksumMismatchError
+	}
+
+	return DecodeAll(false, b[:n])
+}
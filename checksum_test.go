@@ -0,0 +1,56 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChecksummed(t *testing.T) {
+	b, err := EncodeChecksummed(1, "hello", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := MustEncode(1, "hello", nil, true)
+	if !bytes.Equal(b[:len(b)-CHECKSUM_LEN], payload) {
+		t.Error("expected prefix", payload, "got", b)
+	}
+
+	values, err := DecodeChecksummed(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(values) != 4 || values[0] != int64(1) || string(values[1].([]byte)) != "hello" || values[2] != nil || values[3] != true {
+		t.Error("unexpected values", values)
+	}
+
+	// flip a bit in the payload and in the checksum
+	for _, i := range []int{0, 3, len(b) - 1} {
+		cb := append([]byte{}, b...)
+		cb[i] ^= 0x10
+
+		if _, err := DecodeChecksummed(cb); err != ChecksumMismatchError {
+			t.Error(i, "expected", ChecksumMismatchError, "got", err)
+		}
+	}
+
+	if _, err := DecodeChecksummed(b[:CHECKSUM_LEN-1]); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	// empty payload
+	b, _ = EncodeChecksummed()
+	if values, err := DecodeChecksummed(b); err != nil || len(values) != 0 {
+		t.Error("expected no values, got", values, err)
+	}
+}
+
+func TestChecksummedOrder(t *testing.T) {
+	a, _ := EncodeChecksummed("a", 2)
+	b, _ := EncodeChecksummed("b", 1)
+
+	if Compare(a[:len(a)-CHECKSUM_LEN], b[:len(b)-CHECKSUM_LEN]) != -1 {
+		t.Error(a, "should be less than", b)
+	}
+}
@@ -0,0 +1,24 @@
+package typedbuffer
+
+//
+// A Normalizer returns the normalized form of a string.
+// It is satisfied by the Unicode normalization forms in golang.org/x/text/unicode/norm
+// (i.e. norm.NFC or norm.NFKC), without this package depending on it
+//
+type Normalizer interface {
+	String(s string) string
+}
+
+//
+// Encode string after normalizing it with form, so that equivalent strings
+// (i.e. composed and decomposed forms of the same text) produce identical keys.
+// If form is nil the string is encoded as is (as EncodeString).
+// Note that the original string cannot be recovered from the encoded value
+//
+func EncodeStringSorted(s string, form Normalizer) []byte {
+	if form != nil {
+		s = form.String(s)
+	}
+
+	return EncodeString(s)
+}
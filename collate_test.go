@@ -0,0 +1,42 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// composes the few decomposed sequences used in the tests (a stand-in for norm.NFC)
+type testNormalizer struct {
+	r *strings.Replacer
+}
+
+func (n testNormalizer) String(s string) string {
+	return n.r.Replace(s)
+}
+
+var testNFC = testNormalizer{strings.NewReplacer("é", "é", "ä", "ä")}
+
+func TestEncodeStringSorted(t *testing.T) {
+	composed := "café"
+	decomposed := "café"
+
+	if bytes.Equal(EncodeString(composed), EncodeString(decomposed)) {
+		t.Fatal("expected different raw encodings")
+	}
+
+	a := EncodeStringSorted(composed, testNFC)
+	b := EncodeStringSorted(decomposed, testNFC)
+
+	if !bytes.Equal(a, b) {
+		t.Error("expected", a, "got", b)
+	}
+
+	if v, _ := DecodeOne(b); string(v.([]byte)) != composed {
+		t.Error("expected", composed, "got", v)
+	}
+
+	if s := EncodeStringSorted(decomposed, nil); !bytes.Equal(s, EncodeString(decomposed)) {
+		t.Error("expected", EncodeString(decomposed), "got", s)
+	}
+}
@@ -0,0 +1,470 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"time"
+)
+
+//
+// Compare two typed buffers value by value, returning -1, 0 or 1.
+//
+// Unlike bytes.Compare, byte slices are compared according to their content,
+// so that shorter slices sort before longer slices with the same prefix.
+// Values of different types are sorted according to their type tag, except for ints and uints
+// that are compared by their numeric value (so that EncodeInt64(5) and EncodeUint64(5) are equal).
+// If a buffer cannot be decoded the rest of the buffers are compared with bytes.Compare.
+//
+func Compare(a, b []byte) int {
+	for {
+		switch {
+		case len(a) == 0 && len(b) == 0:
+			return 0
+
+		case len(a) == 0:
+			return -1
+
+		case len(b) == 0:
+			return 1
+		}
+
+		va, na, erra := Decode(a)
+		vb, nb, errb := Decode(b)
+		if erra != nil || errb != nil {
+			return bytes.Compare(a, b)
+		}
+
+		if c := compareField(a, va, b, vb); c != 0 {
+			return c
+		}
+
+		a, b = na, nb
+	}
+}
+
+//
+// Compare the first fields of typed buffers a and b, as Compare, but only up to the given
+// number of fields, ignoring the rest. If a key has less than fields values,
+// it sorts before a longer key with the same values.
+// Returns an error if one of the compared fields cannot be decoded
+//
+func ComparePrefix(a, b []byte, fields int) (int, error) {
+	for i := 0; i < fields; i++ {
+		switch {
+		case len(a) == 0 && len(b) == 0:
+			return 0, nil
+
+		case len(a) == 0:
+			return -1, nil
+
+		case len(b) == 0:
+			return 1, nil
+		}
+
+		va, na, err := Decode(a)
+		if err != nil {
+			return 0, err
+		}
+
+		vb, nb, err := Decode(b)
+		if err != nil {
+			return 0, err
+		}
+
+		if c := compareField(a, va, b, vb); c != 0 {
+			return c, nil
+		}
+
+		a, b = na, nb
+	}
+
+	return 0, nil
+}
+
+//
+// Return a function comparing records with a fixed schema, where field i has kind kinds[i]
+// (or is nil). Results are the same as Compare, but ints and bytes are compared without
+// decoding them to interface{} values. Fields after the schema are compared as in Compare.
+// The comparator returns an error wrapping CorruptedBufferError if a field doesn't match the schema
+//
+func CompareFunc(kinds []Kind) func(a, b []byte) (int, error) {
+	kinds = append([]Kind(nil), kinds...)
+	for i, k := range kinds {
+		if k == String { // strings are encoded as bytes
+			kinds[i] = Bytes
+		}
+	}
+
+	return func(a, b []byte) (int, error) {
+		for _, k := range kinds {
+			switch {
+			case len(a) == 0 && len(b) == 0:
+				return 0, nil
+
+			case len(a) == 0:
+				return -1, nil
+
+			case len(b) == 0:
+				return 1, nil
+			}
+
+			if err := checkKind(a, k); err != nil {
+				return 0, err
+			}
+
+			if err := checkKind(b, k); err != nil {
+				return 0, err
+			}
+
+			c, la, lb, err := compareKind(a, b, k)
+			if err != nil {
+				return 0, err
+			}
+
+			if c != 0 {
+				return c, nil
+			}
+
+			a, b = a[la:], b[lb:]
+		}
+
+		return Compare(a, b), nil
+	}
+}
+
+//
+// Compare the first values of typed buffers a and b, that must be integers (int or uint,
+// but not big integers), without decoding them into interface{} values.
+// Ints and uints are compared by their numeric value.
+// Returns an error wrapping CorruptedBufferError if one of the values is not an integer
+//
+func CompareInt(a, b []byte) (int, error) {
+	na, ia, ua, _, err := integerValue(a)
+	if err != nil {
+		return 0, err
+	}
+
+	nb, ib, ub, _, err := integerValue(b)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case na && nb:
+		switch {
+		case ia < ib:
+			return -1, nil
+		case ia > ib:
+			return 1, nil
+		}
+
+	case na:
+		return -1, nil
+
+	case nb:
+		return 1, nil
+
+	case ua < ub:
+		return -1, nil
+
+	case ua > ub:
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+//
+// Return the integer at the start of b, as a negative int64 (with neg set) or as a uint64,
+// and the length of the encoded value
+//
+func integerValue(b []byte) (bool, int64, uint64, int, error) {
+	if len(b) == 0 {
+		return false, 0, 0, 0, EmptyBufferError
+	}
+
+	if i, n, ok := int64Value(b); ok {
+		if i < 0 {
+			return true, i, 0, n, nil
+		}
+
+		return false, 0, uint64(i), n, nil
+	}
+
+	if u, n, ok := uint64Value(b); ok {
+		return false, 0, u, n, nil
+	}
+
+	return false, 0, 0, 0, fmt.Errorf("%w: invalid integer (tag %02x)", CorruptedBufferError, b[0])
+}
+
+//
+// Return an error if the first value in b is not of kind k or nil
+// (ints and uints match either integer kind, since Compare compares them numerically)
+//
+func checkKind(b []byte, k Kind) error {
+	kb, err := TypeOf(b)
+	if err != nil {
+		return err
+	}
+
+	if numericKind(k) && numericKind(kb) {
+		return nil
+	}
+
+	if kb != k && kb != Nil {
+		return fmt.Errorf("%w: expected %v, got %v", CorruptedBufferError, k, kb)
+	}
+
+	return nil
+}
+
+//
+// Compare the first values in a and b, known to be of kind k (or nil).
+// Returns the result and the lengths of the compared values
+//
+func compareKind(a, b []byte, k Kind) (int, int, int, error) {
+	switch k {
+	case Int, Uint:
+		if _, _, _, la, err := integerValue(a); err == nil {
+			if _, _, _, lb, err := integerValue(b); err == nil {
+				c, err := CompareInt(a, b)
+				return c, la, lb, err
+			}
+		}
+
+	case Bytes:
+		if ba, la, ok := bytesValue(a); ok {
+			if bb, lb, ok := bytesValue(b); ok {
+				return bytes.Compare(ba, bb), la, lb, nil
+			}
+		}
+	}
+
+	va, na, err := Decode(a)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	vb, nb, err := Decode(b)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	return compareField(a, va, b, vb), len(a) - len(na), len(b) - len(nb), nil
+}
+
+//
+// Compare decoded values va and vb, given the buffers they were decoded from
+// (values that are both descending compare in reverse order)
+//
+func compareField(a []byte, va interface{}, b []byte, vb interface{}) int {
+	if a[0] == BB_DESC && b[0] == BB_DESC {
+		return -compareValues(^a[1], va, ^b[1], vb)
+	}
+
+	return compareValues(a[0], va, b[0], vb)
+}
+
+//
+// Compare two decoded values, given their type tags
+//
+func compareValues(ka byte, va interface{}, kb byte, vb interface{}) int {
+	if kindOf(ka) != kindOf(kb) || va == nil || vb == nil {
+		if numericKind(kindOf(ka)) && numericKind(kindOf(kb)) {
+			return compareNumbers(va, vb)
+		}
+
+		return compareTags(ka, kb)
+	}
+
+	switch ta := va.(type) {
+	case bool:
+		return compareTags(ka, kb)
+
+	case []byte:
+		return bytes.Compare(ta, vb.([]byte))
+
+	case int64:
+		tb, ok := vb.(int64)
+		if !ok { // big integer
+			return compareTags(ka, kb)
+		}
+
+		switch {
+		case ta < tb:
+			return -1
+		case ta > tb:
+			return 1
+		}
+
+	case uint64:
+		tb := vb.(uint64)
+		switch {
+		case ta < tb:
+			return -1
+		case ta > tb:
+			return 1
+		}
+
+	case *big.Int:
+		tb, ok := vb.(*big.Int)
+		if !ok {
+			return compareTags(ka, kb)
+		}
+
+		return ta.Cmp(tb)
+
+	case *big.Rat:
+		return ta.Cmp(vb.(*big.Rat))
+
+	case float64:
+		if ka != kb { // special values and signs are ordered by tag
+			return compareTags(ka, kb)
+		}
+
+		tb := vb.(float64)
+		switch {
+		case ta < tb:
+			return -1
+		case ta > tb:
+			return 1
+		}
+
+	case time.Time:
+		return ta.Compare(vb.(time.Time))
+
+	case net.IP:
+		if ka != kb { // IPv4 before IPv6
+			return compareTags(ka, kb)
+		}
+
+		return bytes.Compare(ta, vb.(net.IP))
+
+	case [16]byte:
+		tb := vb.([16]byte)
+		return bytes.Compare(ta[:], tb[:])
+
+	case time.Duration:
+		tb := vb.(time.Duration)
+
+		switch {
+		case ta < tb:
+			return -1
+		case ta > tb:
+			return 1
+		}
+
+	case EnumValue:
+		tb := vb.(EnumValue)
+
+		switch {
+		case ta.Type < tb.Type:
+			return -1
+		case ta.Type > tb.Type:
+			return 1
+		case ta.Value < tb.Value:
+			return -1
+		case ta.Value > tb.Value:
+			return 1
+		}
+
+	case []bool:
+		tb := vb.([]bool)
+		for i := 0; i < len(ta) && i < len(tb); i++ {
+			if ta[i] != tb[i] {
+				if tb[i] {
+					return -1
+				}
+				return 1
+			}
+		}
+
+		switch {
+		case len(ta) < len(tb):
+			return -1
+		case len(ta) > len(tb):
+			return 1
+		}
+	}
+
+	return 0
+}
+
+//
+// Return true if values of kind k are integers (compared by numeric value)
+//
+func numericKind(k Kind) bool {
+	return k == Int || k == Uint
+}
+
+//
+// Compare integer values va and vb (int64, uint64 or *big.Int) by their numeric value
+//
+func compareNumbers(va, vb interface{}) int {
+	switch ta := va.(type) {
+	case int64:
+		if tb, ok := vb.(uint64); ok {
+			switch {
+			case ta < 0 || uint64(ta) < tb:
+				return -1
+			case uint64(ta) > tb:
+				return 1
+			default:
+				return 0
+			}
+		}
+
+	case uint64:
+		if _, ok := vb.(int64); ok {
+			return -compareNumbers(vb, va)
+		}
+	}
+
+	return bigValue(va).Cmp(bigValue(vb))
+}
+
+//
+// Return integer value v (int64, uint64 or *big.Int) as a big.Int
+//
+func bigValue(v interface{}) *big.Int {
+	switch t := v.(type) {
+	case int64:
+		return big.NewInt(t)
+	case uint64:
+		return new(big.Int).SetUint64(t)
+	default:
+		return t.(*big.Int)
+	}
+}
+
+func compareTags(ka, kb byte) int {
+	switch {
+	case ka < kb:
+		return -1
+	case ka > kb:
+		return 1
+	default:
+		return 0
+	}
+}
+
+//
+// KeySlice attaches the methods of sort.Interface to a slice of encoded keys,
+// sorting them in increasing order (as bytes.Compare)
+//
+type KeySlice [][]byte
+
+func (s KeySlice) Len() int           { return len(s) }
+func (s KeySlice) Less(i, j int) bool { return bytes.Compare(s[i], s[j]) < 0 }
+func (s KeySlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+//
+// Sort a slice of encoded keys in increasing order
+//
+func SortKeys(keys [][]byte) {
+	sort.Sort(KeySlice(keys))
+}
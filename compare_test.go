@@ -0,0 +1,263 @@
+package typedbuffer
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestCompareValues(t *testing.T) {
+	tests := []CompareItem{
+		CompareItem{MustEncode("dog"), MustEncode("dogs")},
+		CompareItem{MustEncode("cat"), MustEncode("dog")},
+		CompareItem{MustEncode(""), MustEncode("a")},
+		CompareItem{MustEncode(string(make([]byte, 60))), MustEncode("a")},
+		CompareItem{MustEncode(3, "dog", false), MustEncode(3, "dogs", false)},
+		CompareItem{MustEncode(3, "dog", true), MustEncode(3, "dogs", false)},
+		CompareItem{MustEncode(3, "dogs", false), MustEncode(3, "dogs", true)},
+		CompareItem{MustEncode(-1000000, "z"), MustEncode(-1, "a")},
+		CompareItem{MustEncode(10), MustEncode(int64(10000000000))},
+		CompareItem{MustEncode(uint64(10)), MustEncode(uint64(10000))},
+		CompareItem{MustEncode(1, nil), MustEncode(1, 0)},
+		CompareItem{MustEncode(1, 2), MustEncode(1, 2, 3)},
+		CompareItem{MustEncode(time.Unix(0, 0)), MustEncode(time.Now())},
+		CompareItem{MustEncodeNils(false, "x", 1), MustEncodeNils(false, "x", nil)},
+		CompareItem{MustEncode(new(big.Int).Lsh(big.NewInt(-1), 100)), MustEncode(-1)},
+		CompareItem{MustEncode(1), MustEncode(new(big.Int).Lsh(big.NewInt(1), 100))},
+		CompareItem{MustEncode(new(big.Int).Lsh(big.NewInt(1), 100)), MustEncode(new(big.Int).Lsh(big.NewInt(1), 101))},
+	}
+
+	for _, tt := range tests {
+		if c := Compare(tt.min, tt.max); c != -1 {
+			t.Error(MustDecodeAll(tt.min), "should be less than", MustDecodeAll(tt.max), "got", c)
+		}
+
+		if c := Compare(tt.max, tt.min); c != 1 {
+			t.Error(MustDecodeAll(tt.max), "should be greater than", MustDecodeAll(tt.min), "got", c)
+		}
+
+		if c := Compare(tt.min, tt.min); c != 0 {
+			t.Error(MustDecodeAll(tt.min), "should be equal to itself, got", c)
+		}
+	}
+}
+
+func TestSortKeys(t *testing.T) {
+	values := []int64{-9223372036854775808, -100000, -256, -9, -8, -1, 0, 1, 7, 8, 255, 256, 100000, 9223372036854775807}
+
+	keys := make([][]byte, len(values))
+	for i, v := range values {
+		keys[i] = EncodeInt64(v)
+	}
+
+	rand.New(rand.NewSource(42)).Shuffle(len(keys), func(i, j int) {
+		keys[i], keys[j] = keys[j], keys[i]
+	})
+
+	SortKeys(keys)
+
+	for i, k := range keys {
+		if v, _, _ := Decode(k); v != values[i] {
+			t.Error("expected", values[i], "got", v)
+		}
+	}
+}
+
+func TestComparePrefix(t *testing.T) {
+	tests := []struct {
+		a, b     []byte
+		fields   int
+		expected int
+	}{
+		{MustEncode(1, "z"), MustEncode(1, "a"), 1, 0},
+		{MustEncode(1, "z"), MustEncode(1, "a"), 2, 1},
+		{MustEncode(1, "z"), MustEncode(2, "a"), 1, -1},
+		{MustEncode(1), MustEncode(1, "a"), 1, 0},
+		{MustEncode(1), MustEncode(1, "a"), 2, -1},
+		{MustEncode(1, "a"), MustEncode(1), 2, 1},
+		{MustEncode(1), MustEncode(1), 5, 0},
+		{MustEncode(1, 2), MustEncode(1, 2), 0, 0},
+		{MustEncodeDesc(1, "z"), MustEncodeDesc(2, "a"), 1, 1},
+	}
+
+	for _, tt := range tests {
+		c, err := ComparePrefix(tt.a, tt.b, tt.fields)
+		if err != nil || c != tt.expected {
+			t.Error(MustDecodeAll(tt.a), MustDecodeAll(tt.b), tt.fields, "expected", tt.expected, "got", c, err)
+		}
+	}
+
+	// fields after the prefix are not decoded
+	a := append(MustEncode(1), 0x01)
+	if c, err := ComparePrefix(a, MustEncode(1, 2), 1); err != nil || c != 0 {
+		t.Error("expected 0, got", c, err)
+	}
+
+	if _, err := ComparePrefix(a, MustEncode(1, 2), 2); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestCompareFunc(t *testing.T) {
+	cmp := CompareFunc([]Kind{Int, Bytes, Bool})
+	scmp := CompareFunc([]Kind{Int, String, Bool})
+
+	records := [][]byte{
+		MustEncode(-100000, "b", true),
+		MustEncode(-1, "a", false),
+		MustEncode(1, "", true),
+		MustEncode(1, "a", false),
+		MustEncode(1, "a", true),
+		MustEncode(1, "a", true, 5),
+		MustEncode(uint64(1), "a", true),
+		MustEncode(1, "ab", false),
+		MustEncode(uint64(17), "a", false),
+		MustEncode(300, nil, false),
+		MustEncode(300, "a", false),
+		MustEncode(uint64(math.MaxUint64), "a", false),
+		MustEncode(new(big.Int).Lsh(big.NewInt(1), 70), "a", false),
+		MustEncode(nil, "a", false),
+	}
+
+	for _, a := range records {
+		for _, b := range records {
+			c, err := cmp(a, b)
+			if err != nil {
+				t.Fatal(MustDecodeAll(a), MustDecodeAll(b), err)
+			}
+
+			if expected := Compare(a, b); c != expected {
+				t.Error(MustDecodeAll(a), MustDecodeAll(b), "expected", expected, "got", c)
+			}
+
+			if sc, err := scmp(a, b); err != nil || sc != c {
+				t.Error(MustDecodeAll(a), MustDecodeAll(b), "String schema: expected", c, "got", sc, err)
+			}
+		}
+	}
+
+	if c, err := CompareFunc([]Kind{Int})(EncodeInt64(5), EncodeUint64(5)); err != nil || c != 0 {
+		t.Error("expected int and uint to compare equal, got", c, err)
+	}
+
+	if _, err := cmp(MustEncode(1, 2, true), MustEncode(1, "a", true)); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, err := cmp(MustEncode(1, "a", true), MustEncode(1, "a", 1)); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func BenchmarkCompareFunc(b *testing.B) {
+	cmp := CompareFunc([]Kind{Int, Bytes, Bool})
+	x, y := MustEncode(100000, "hello, world", true), MustEncode(100000, "hello, world", false)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		cmp(x, y)
+	}
+}
+
+func BenchmarkCompare(b *testing.B) {
+	x, y := MustEncode(100000, "hello, world", true), MustEncode(100000, "hello, world", false)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		Compare(x, y)
+	}
+}
+
+func TestCompareInt(t *testing.T) {
+	// in increasing order
+	values := [][]byte{
+		EncodeInt64(math.MinInt64),
+		EncodeInt64(-257),
+		EncodeInt64(-9),
+		EncodeInt64(-8),
+		EncodeInt64(-1),
+		EncodeInt64(0),
+		EncodeUint64(1),
+		EncodeInt64(7),
+		EncodeInt64(8),
+		EncodeUint64(17),
+		EncodeInt64(1000),
+		EncodeInt64(math.MaxInt64),
+		EncodeUint64(math.MaxUint64),
+	}
+
+	for i, a := range values {
+		for j, b := range values {
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+
+			if c, err := CompareInt(a, b); err != nil || c != expected {
+				t.Error(MustDecodeAll(a), MustDecodeAll(b), "expected", expected, "got", c, err)
+			}
+		}
+	}
+
+	// ints and uints with the same value are equal
+	if c, err := CompareInt(EncodeInt64(1000), EncodeUint64(1000)); err != nil || c != 0 {
+		t.Error("expected 0, got", c, err)
+	}
+
+	if _, err := CompareInt(EncodeInt64(1), MustEncode("1")); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, err := CompareInt(nil, EncodeInt64(1)); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+
+	if _, err := CompareInt(EncodeInt64(1000)[:2], EncodeInt64(1)); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestCompareIntUint(t *testing.T) {
+	if c := Compare(EncodeInt64(5), EncodeUint64(5)); c != 0 {
+		t.Error("expected 0, got", c)
+	}
+
+	if c := Compare(MustEncode(1, uint64(5), "a"), MustEncode(1, 5, "b")); c != -1 {
+		t.Error("expected -1, got", c)
+	}
+
+	big64 := new(big.Int).Lsh(big.NewInt(1), 64)
+
+	// in increasing order, mixing ints, uints and big ints
+	values := []interface{}{
+		new(big.Int).Neg(big64), int64(math.MinInt64), int64(-1), uint64(0), int64(1), uint64(2),
+		int64(1000), uint64(1001), int64(math.MaxInt64), uint64(math.MaxUint64), big64,
+	}
+
+	for i, a := range values {
+		for j, b := range values {
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+
+			if c := Compare(MustEncode(a), MustEncode(b)); c != expected {
+				t.Errorf("%T(%v) %T(%v): expected %v, got %v", a, a, b, b, expected, c)
+			}
+		}
+	}
+
+	// other kinds are still sorted by tag
+	if c := Compare(EncodeUint64(5), EncodeFloat64(5)); c != -1 {
+		t.Error("expected -1, got", c)
+	}
+}
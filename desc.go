@@ -0,0 +1,101 @@
+package typedbuffer
+
+//
+// Encode one or more values in descending order, so that the natural order
+// of the encoded buffers is the reverse of the natural order of the values.
+// Note that nil values (encoded as nil first) will come after any other value.
+// Slices are encoded as one descending value per element.
+//
+func EncodeDesc(values ...interface{}) ([]byte, error) {
+	b := []byte{}
+
+	for _, v := range values {
+		enc, err := Encode(v)
+		if err != nil {
+			return nil, err
+		}
+
+		// slices encode to several values, each one gets its own descending tag
+		for len(enc) > 0 {
+			n, err := ValueLen(enc)
+			if err != nil {
+				return nil, err
+			}
+
+			b = appendDesc(b, enc[:n])
+			enc = enc[n:]
+		}
+	}
+
+	return b, nil
+}
+
+//
+// Encode uint64 value in descending order, so that larger values sort first
+// (e.g. for "newest first" timestamp keys)
+//
+func EncodeUint64Desc(u uint64) []byte {
+	return appendDesc(make([]byte, 0, 1+uint64Len(u)), AppendUint64(nil, u))
+}
+
+//
+// Decode first value in typed buffer as a descending uint64 (see EncodeUint64Desc).
+// Returns decoded value and remaining buffer, or CorruptedBufferError if the value
+// is not a descending uint64
+//
+func DecodeUint64Desc(b []byte) (uint64, []byte, error) {
+	if len(b) == 0 {
+		return 0, nil, EmptyBufferError
+	}
+
+	if b[0] != BB_DESC {
+		return 0, nil, CorruptedBufferError
+	}
+
+	v, next, err := Decode(b)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	u, ok := v.(uint64)
+	if !ok {
+		return 0, nil, CorruptedBufferError
+	}
+
+	return u, next, nil
+}
+
+//
+// Append the descending form of encoded value enc to b
+//
+func appendDesc(b, enc []byte) []byte {
+	b = append(b, BB_DESC)
+
+	for _, c := range enc {
+		b = append(b, ^c)
+	}
+
+	return b
+}
+
+//
+// Decode descending value (including the tag). Returns decoded value and remaining buffer
+//
+func decodeDesc(b []byte) (interface{}, []byte, error) {
+	n, err := valueLen(b, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bb := make([]byte, n-1)
+	for i := range bb {
+		bb[i] = ^b[1+i]
+	}
+
+	v, _, err := decode(bb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return v, b[n:], nil
+}
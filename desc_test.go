@@ -0,0 +1,143 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func MustEncodeDesc(values ...interface{}) []byte {
+	b, err := EncodeDesc(values...)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestEncodeDesc(t *testing.T) {
+	tests := []CompareItem{
+		CompareItem{MustEncodeDesc(100), MustEncodeDesc(10)},
+		CompareItem{MustEncodeDesc(int64(10000000000)), MustEncodeDesc(10)},
+		CompareItem{MustEncodeDesc(1), MustEncodeDesc(-1)},
+		CompareItem{MustEncodeDesc(-1), MustEncodeDesc(-1000000000)},
+		CompareItem{MustEncodeDesc(uint64(1000)), MustEncodeDesc(uint64(1))},
+		CompareItem{MustEncodeDesc("dog"), MustEncodeDesc("cat")},
+		CompareItem{MustEncodeDesc(true), MustEncodeDesc(false)},
+		CompareItem{MustEncodeDesc(time.Now()), MustEncodeDesc(time.Unix(0, 0))},
+		CompareItem{MustEncodeDesc(0), MustEncodeDesc(nil)},
+		CompareItem{append(MustEncode(1), MustEncodeDesc(50)...), append(MustEncode(1), MustEncodeDesc(3)...)},
+		CompareItem{append(MustEncode(1), MustEncodeDesc(3)...), append(MustEncode(2), MustEncodeDesc(50)...)},
+	}
+
+	for _, tt := range tests {
+		t.Log(MustDecodeAll(tt.min), "<", MustDecodeAll(tt.max), "[", tt.min, "<", tt.max, "]")
+		if bytes.Compare(tt.min, tt.max) != -1 {
+			t.Log(tt.min, "should be less than", tt.max)
+			t.Fail()
+		}
+
+		if Compare(tt.min, tt.max) != -1 {
+			t.Log(tt.min, "should compare less than", tt.max)
+			t.Fail()
+		}
+	}
+}
+
+func TestDecodeDesc(t *testing.T) {
+	b := MustEncodeDesc(-100000, "hello", uint64(1000), nil, true)
+	b = append(b, MustEncode(42)...)
+
+	res := MustDecodeAll(b)
+	expected := []interface{}{int64(-100000), "hello", uint64(1000), nil, true, int64(42)}
+
+	if len(res) != len(expected) {
+		t.Fatal("expected", expected, "got", res)
+	}
+
+	for i, v := range res {
+		if v != expected[i] {
+			t.Error("expected", expected[i], "got", v)
+		}
+	}
+
+	if _, _, err := Decode(b[:3]); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := Decode([]byte{BB_DESC}); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestEncodeDescSlice(t *testing.T) {
+	tests := []struct {
+		v        interface{}
+		expected []interface{}
+	}{
+		{[]uint64{1, 2}, []interface{}{uint64(1), uint64(2)}},
+		{[]string{"a", "b"}, []interface{}{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		b := MustEncodeDesc(tt.v)
+
+		res, err := DecodeAll(true, b)
+		if err != nil {
+			t.Error(tt.v, "decode error", err)
+			continue
+		}
+
+		if len(res) != len(tt.expected) {
+			t.Error("expected", tt.expected, "got", res)
+			continue
+		}
+
+		for i, v := range res {
+			if v != tt.expected[i] {
+				t.Error("expected", tt.expected[i], "got", v)
+			}
+		}
+	}
+}
+
+func TestEncodeUint64Desc(t *testing.T) {
+	if bytes.Compare(EncodeUint64Desc(100), EncodeUint64Desc(1)) != -1 {
+		t.Error(EncodeUint64Desc(100), "should be less than", EncodeUint64Desc(1))
+	}
+
+	values := []uint64{18446744073709551615, 1 << 40, 65536, 256, 17, 16, 1, 0}
+
+	var prev []byte
+
+	for _, u := range values {
+		b := EncodeUint64Desc(u)
+
+		if expected := MustEncodeDesc(u); !bytes.Equal(b, expected) {
+			t.Error("expected", expected, "got", b)
+		}
+
+		if prev != nil && bytes.Compare(prev, b) != -1 {
+			t.Error(prev, "should be less than", b)
+		}
+
+		d, next, err := DecodeUint64Desc(append(b, One...))
+		if err != nil || d != u || !bytes.Equal(next, One) {
+			t.Error("expected", u, "got", d, next, err)
+		}
+
+		prev = b
+	}
+
+	if _, _, err := DecodeUint64Desc(EncodeUint64(1)); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := DecodeUint64Desc(MustEncodeDesc(1)); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := DecodeUint64Desc(nil); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+}
@@ -0,0 +1,60 @@
+package typedbuffer
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+//
+// Return a human readable representation of the values in a typed buffer,
+// i.e. `int64(10) string("hi") nil`. If the buffer is corrupted the output
+// ends with a description of the error.
+//
+func Dump(b []byte) string {
+	var parts []string
+
+	it := NewIterator(b)
+	for it.Next() {
+		parts = append(parts, dumpValue(it.Value()))
+	}
+
+	if err := it.Err(); err != nil {
+		parts = append(parts, fmt.Sprintf("<%v>", err))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func dumpValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "nil"
+
+	case []byte:
+		if utf8.Valid(t) {
+			return fmt.Sprintf("string(%q)", t)
+		}
+		return fmt.Sprintf("bytes(%x)", t)
+
+	case time.Time:
+		return fmt.Sprintf("time(%v)", t.Format(time.RFC3339Nano))
+
+	case *big.Int:
+		return fmt.Sprintf("big.Int(%v)", t)
+
+	case *big.Rat:
+		return fmt.Sprintf("big.Rat(%v)", t)
+
+	case [16]byte:
+		return fmt.Sprintf("uuid(%x-%x-%x-%x-%x)", t[0:4], t[4:6], t[6:8], t[8:10], t[10:16])
+
+	case EnumValue:
+		return fmt.Sprintf("enum(%d:%d)", t.Type, t.Value)
+
+	default:
+		return fmt.Sprintf("%T(%v)", v, v)
+	}
+}
@@ -0,0 +1,27 @@
+package typedbuffer
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestDump(t *testing.T) {
+	tests := []struct {
+		b        []byte
+		expected string
+	}{
+		{MustEncode(10, "hi", nil), `int64(10) string("hi") nil`},
+		{MustEncode(uint64(1000), true, []byte{0xff, 0x00}), `uint64(1000) bool(true) bytes(ff00)`},
+		{MustEncode(time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC)), `time(2015-01-01T00:00:00Z)`},
+		{MustEncode(new(big.Int).Lsh(big.NewInt(1), 64)), `big.Int(18446744073709551616)`},
+		{[]byte{}, ``},
+		{append(MustEncode(10), 0x01), `int64(10) <corrupted buffer at offset 2 (tag 01): invalid tag>`},
+	}
+
+	for _, tt := range tests {
+		if d := Dump(tt.b); d != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, d)
+		}
+	}
+}
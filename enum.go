@@ -0,0 +1,44 @@
+package typedbuffer
+
+//
+// A decoded enum value: an integer constant tagged with the identifier of its enum type
+//
+type EnumValue struct {
+	Type  uint16
+	Value int64
+}
+
+//
+// Encode enum-like integer constant value of type typeID.
+// Values of the same type sort by value, and values of different types sort by type id,
+// so two enums with the same integer value but different types are never equal
+//
+func EncodeEnum(typeID uint16, value int64) []byte {
+	return appendEnum(make([]byte, 0, 11), EnumValue{Type: typeID, Value: value})
+}
+
+//
+// Append encoded enum value e to dst
+//
+func appendEnum(dst []byte, e EnumValue) []byte {
+	dst = append(dst, BB_ENUM, byte(e.Type>>8), byte(e.Type))
+	return appendFixed64(dst, uint64(e.Value)^DATE_SIGN_BIT)
+}
+
+//
+// Decode first value in typed buffer as an enum. Returns the type id, the value and the remaining buffer,
+// or CorruptedBufferError if the value is not an enum
+//
+func DecodeEnum(b []byte) (uint16, int64, []byte, error) {
+	v, next, err := Decode(b)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	e, ok := v.(EnumValue)
+	if !ok {
+		return 0, 0, nil, CorruptedBufferError
+	}
+
+	return e.Type, e.Value, next, nil
+}
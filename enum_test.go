@@ -0,0 +1,98 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEncodeEnum(t *testing.T) {
+	enums := []EnumValue{
+		{0, math.MinInt64},
+		{0, -1},
+		{0, 0},
+		{0, 1},
+		{0, math.MaxInt64},
+		{1, math.MinInt64},
+		{1, 0},
+		{1, 42},
+		{0x100, -5},
+		{math.MaxUint16, 0},
+	}
+
+	var prev []byte
+
+	for _, e := range enums {
+		b := EncodeEnum(e.Type, e.Value)
+
+		if len(b) != 11 || !bytes.Equal(b, MustEncode(e)) {
+			t.Error(e, "unexpected encoding", b)
+		}
+
+		if l, err := EncodedLen(e); err != nil || l != len(b) {
+			t.Error(e, "expected length", len(b), "got", l, err)
+		}
+
+		if prev != nil && (bytes.Compare(prev, b) != -1 || Compare(prev, b) != -1) {
+			t.Error(prev, "should be less than", b)
+		}
+
+		typeID, value, next, err := DecodeEnum(append(b, One...))
+		if err != nil || typeID != e.Type || value != e.Value || !bytes.Equal(next, One) {
+			t.Error(e, "got", typeID, value, next, err)
+		}
+
+		if k, _ := TypeOf(b); k != Enum {
+			t.Error("expected", Enum, "got", k)
+		}
+
+		prev = b
+	}
+}
+
+func TestEnumTypeDiscriminator(t *testing.T) {
+	// same integer value, different enum types
+	a := EncodeEnum(1, 7)
+	b := EncodeEnum(2, 7)
+
+	if bytes.Equal(a, b) {
+		t.Fatal("enums of different types should not be equal")
+	}
+
+	if bytes.Compare(a, b) != -1 || Compare(a, b) != -1 {
+		t.Error(a, "should be less than", b)
+	}
+
+	// a greater value of a lower type still sorts first
+	if c := EncodeEnum(1, math.MaxInt64); bytes.Compare(c, b) != -1 {
+		t.Error(c, "should be less than", b)
+	}
+
+	// and an enum is never equal to the plain integer
+	if bytes.Equal(a, EncodeInt64(7)) {
+		t.Error("enum should not be equal to integer")
+	}
+
+	if dump := Dump(a); dump != "enum(1:7)" {
+		t.Error("expected enum(1:7) got", dump)
+	}
+}
+
+func TestDecodeEnumErrors(t *testing.T) {
+	b := EncodeEnum(3, 1000)
+
+	for i := 1; i < len(b); i++ {
+		if _, _, _, err := DecodeEnum(b[:i]); !errors.Is(err, CorruptedBufferError) {
+			t.Error(b[:i], "expected", CorruptedBufferError, "got", err)
+		}
+	}
+
+	if _, _, _, err := DecodeEnum(EncodeInt64(1000)); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, _, err := DecodeEnum(nil); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+}
@@ -0,0 +1,94 @@
+package typedbuffer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+//
+// A FramedWriter writes records to an io.Writer, each one prefixed by its length as a uvarint,
+// so that record boundaries can be found without decoding the values
+//
+type FramedWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+//
+// Create a new FramedWriter that writes records to w
+//
+func NewFramedWriter(w io.Writer) *FramedWriter {
+	return &FramedWriter{w: w}
+}
+
+//
+// Encode values as one record and write it, prefixed by its length
+//
+func (fw *FramedWriter) WriteRecord(values ...interface{}) error {
+	var hdr [binary.MaxVarintLen64]byte
+
+	// encode the payload after room for the longest length, so that the length is the actual one
+	fw.buf = append(fw.buf[:0], hdr[:]...)
+
+	var err error
+	if fw.buf, err = appendValues(fw.buf, true, values); err != nil {
+		return err
+	}
+
+	l := binary.PutUvarint(hdr[:], uint64(len(fw.buf)-len(hdr)))
+	start := len(hdr) - l
+	copy(fw.buf[start:], hdr[:l])
+
+	_, err = fw.w.Write(fw.buf[start:])
+	return err
+}
+
+//
+// A FramedReader reads records written by a FramedWriter
+//
+type FramedReader struct {
+	r *bufio.Reader
+}
+
+//
+// Create a new FramedReader that reads records from r
+//
+func NewFramedReader(r io.Reader) *FramedReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return &FramedReader{r: br}
+}
+
+//
+// Read and decode the next record.
+// Returns io.EOF when the stream is exhausted and io.ErrUnexpectedEOF
+// if the stream ends in the middle of a record
+//
+func (fr *FramedReader) ReadRecord() ([]interface{}, error) {
+	n, err := binary.ReadUvarint(fr.r)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(n) < 0 {
+		return nil, CorruptedBufferError
+	}
+
+	// don't trust the length prefix to preallocate the record
+	var buf bytes.Buffer
+
+	if _, err := io.CopyN(&buf, fr.r, int64(n)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+
+	return DecodeAll(false, buf.Bytes())
+}
@@ -0,0 +1,83 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestFramedRecords(t *testing.T) {
+	records := [][]interface{}{
+		{int64(1), "first", true},
+		{},
+		{nil, int64(-1000), 1.5},
+		{string(make([]byte, 300))},
+	}
+
+	r, w := io.Pipe()
+
+	go func() {
+		fw := NewFramedWriter(w)
+
+		for _, rec := range records {
+			if err := fw.WriteRecord(rec...); err != nil {
+				w.CloseWithError(err)
+				return
+			}
+		}
+
+		w.Close()
+	}()
+
+	fr := NewFramedReader(r)
+
+	for i, rec := range records {
+		values, err := fr.ReadRecord()
+		if err != nil {
+			t.Fatal(i, err)
+		}
+
+		if len(values) != len(rec) {
+			t.Fatal(i, "expected", rec, "got", values)
+		}
+
+		for j, v := range values {
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+
+			if v != rec[j] {
+				t.Error(i, j, "expected", rec[j], "got", v)
+			}
+		}
+	}
+
+	if _, err := fr.ReadRecord(); err != io.EOF {
+		t.Error("expected", io.EOF, "got", err)
+	}
+}
+
+func TestFramedErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	fw := NewFramedWriter(&buf)
+	if err := fw.WriteRecord(1, "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.Bytes()[0] != byte(len(MustEncode(1, "hello"))) {
+		t.Error("expected length prefix, got", buf.Bytes())
+	}
+
+	if err := fw.WriteRecord(1, map[int]int{}); err == nil {
+		t.Error("expected error")
+	}
+
+	b := buf.Bytes()
+
+	for _, l := range []int{1, len(b) - 1} {
+		if _, err := NewFramedReader(bytes.NewReader(b[:l])).ReadRecord(); err != io.ErrUnexpectedEOF {
+			t.Error(l, "expected", io.ErrUnexpectedEOF, "got", err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte{})
+	f.Add(MustEncode(10, "hello", -100000, false, nil))
+	f.Add(MustEncode(uint64(18446744073709551615), int64(-9223372036854775808), time.Now()))
+	f.Add(MustEncode(make([]byte, 100), make([]byte, 1000)))
+	f.Add(MustEncodeDesc(1000, "hello"))
+	f.Add(EncodeBytesOrdered([]byte("a\x00b")))
+	f.Add([]byte{BB_BYTES_LEN_4, 0xFF, 0xFF, 0xFF, 0xFF})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		v, next, err := Decode(b)
+
+		rest, serr := SkipValue(b)
+		if (err == nil) != (serr == nil) {
+			t.Fatalf("Decode and SkipValue disagree: %v %v", err, serr)
+		}
+
+		if err == nil {
+			if !bytes.Equal(next, rest) {
+				t.Fatalf("Decode and SkipValue disagree: %v %v", next, rest)
+			}
+
+			if _, terr := TypeOf(b); terr != nil {
+				t.Fatalf("TypeOf failed on decoded value %v: %v", v, terr)
+			}
+		}
+
+		DecodeAll(true, b)
+		Compare(b, b)
+
+		dec := NewDecoder(bytes.NewReader(b))
+		for {
+			if _, err := dec.Decode(); err != nil {
+				break
+			}
+		}
+	})
+}
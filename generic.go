@@ -0,0 +1,238 @@
+package typedbuffer
+
+import (
+	"cmp"
+	"math"
+	"reflect"
+)
+
+//
+// Integer is the set of Go integer types
+//
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+//
+// Decode first value in typed buffer as a value of type T.
+// Returns decoded value and remaining buffer, or CorruptedBufferError if the value is not of type T.
+// Byte slices can be decoded as strings, and integers as any integer type (as CoerceTo does,
+// returning ValueOutOfRangeError if the value doesn't fit in T).
+// Nil is decoded as the zero value of types that can hold nil (interfaces, pointers, slices...).
+//
+func DecodeAs[T any](b []byte) (T, []byte, error) {
+	var zero T
+
+	v, next, err := Decode(b)
+	if err != nil {
+		return zero, nil, err
+	}
+
+	if _, ok := interface{}(zero).(string); ok {
+		if sb, ok := v.([]byte); ok {
+			v = string(sb)
+		}
+	}
+
+	if t, ok := v.(T); ok {
+		return t, next, nil
+	}
+
+	if v == nil && nillable(reflect.TypeOf(&zero).Elem()) {
+		return zero, next, nil
+	}
+
+	var t T
+
+	if err := coerceReflect(reflect.ValueOf(&t).Elem(), v); err != nil {
+		return zero, nil, err
+	}
+
+	return t, next, nil
+}
+
+//
+// Return true if the zero value of type t is nil
+//
+func nillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
+		return true
+
+	default:
+		return false
+	}
+}
+
+//
+// Set integer rv to decoded integer value v (int64 or uint64), with the same checks as CoerceTo.
+// Returns CorruptedBufferError if rv or v are not integers
+//
+func coerceReflect(rv reflect.Value, v interface{}) error {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+
+		switch t := v.(type) {
+		case int64:
+			i = t
+		case uint64:
+			if t > math.MaxInt64 {
+				return ValueOutOfRangeError
+			}
+			i = int64(t)
+		default:
+			return CorruptedBufferError
+		}
+
+		if rv.OverflowInt(i) {
+			return ValueOutOfRangeError
+		}
+
+		rv.SetInt(i)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+
+		switch t := v.(type) {
+		case int64:
+			if t < 0 {
+				return ValueOutOfRangeError
+			}
+			u = uint64(t)
+		case uint64:
+			u = t
+		default:
+			return CorruptedBufferError
+		}
+
+		if rv.OverflowUint(u) {
+			return ValueOutOfRangeError
+		}
+
+		rv.SetUint(u)
+		return nil
+	}
+
+	return CorruptedBufferError
+}
+
+//
+// Encode all elements of a slice
+//
+func EncodeSlice[T any](xs []T) ([]byte, error) {
+	b := []byte{}
+
+	for _, x := range xs {
+		enc, err := Encode(x)
+		if err != nil {
+			return nil, err
+		}
+
+		b = append(b, enc...)
+	}
+
+	return b, nil
+}
+
+//
+// Decode all values in a typed buffer as a slice of values of type T
+//
+func DecodeSlice[T any](b []byte) ([]T, error) {
+	res := []T{}
+
+	for len(b) > 0 {
+		v, next, err := DecodeAs[T](b)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, v)
+		b = next
+	}
+
+	return res, nil
+}
+
+//
+// Convert a decoded integer value (int64 or uint64) to integer type T.
+// Returns ValueOutOfRangeError if the value doesn't fit in T,
+// or CorruptedBufferError if the value is not an integer
+//
+func CoerceTo[T Integer](v interface{}) (T, error) {
+	var zero T
+
+	switch t := v.(type) {
+	case int64:
+		r := T(t)
+		if int64(r) != t || (r < 0) != (t < 0) {
+			return zero, ValueOutOfRangeError
+		}
+		return r, nil
+
+	case uint64:
+		r := T(t)
+		if uint64(r) != t || r < 0 {
+			return zero, ValueOutOfRangeError
+		}
+		return r, nil
+
+	default:
+		return zero, CorruptedBufferError
+	}
+}
+
+//
+// Encode an ordered value (integer, float or string, including types derived from them)
+// with the order-preserving encoder for its kind.
+// Strings are encoded as ordered bytes (see EncodeBytesOrdered), so that they sort lexicographically
+//
+func EncodeComparable[T cmp.Ordered](v T) []byte {
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return EncodeInt64(rv.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return EncodeUint64(rv.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		return EncodeFloat64(rv.Float())
+
+	default: // string
+		return EncodeBytesOrdered([]byte(rv.String()))
+	}
+}
+
+//
+// Encode optional value v: a nil pointer is encoded as nil (first or last, according to nilFirst),
+// otherwise the value it points to is encoded
+//
+func EncodeNullable[T any](v *T, nilFirst bool) ([]byte, error) {
+	if v == nil {
+		return AppendNil(nil, nilFirst), nil
+	}
+
+	return EncodeNils(nilFirst, *v)
+}
+
+//
+// Decode first value in typed buffer as an optional value of type T (see DecodeAs).
+// Returns a nil pointer if the value is nil, and the remaining buffer
+//
+func DecodeNullable[T any](b []byte) (*T, []byte, error) {
+	if IsNil(b) {
+		_, next, err := Decode(b)
+		return nil, next, err
+	}
+
+	v, next, err := DecodeAs[T](b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &v, next, nil
+}
@@ -0,0 +1,373 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDecodeAs(t *testing.T) {
+	now := time.Now().Truncate(time.Millisecond)
+	b := MustEncode(-100000, "hello", uint64(1000), true, now, "bytes")
+
+	i, b, err := DecodeAs[int64](b)
+	if err != nil || i != -100000 {
+		t.Error("expected -100000, got", i, err)
+	}
+
+	s, b, err := DecodeAs[string](b)
+	if err != nil || s != "hello" {
+		t.Error("expected hello, got", s, err)
+	}
+
+	u, b, err := DecodeAs[uint64](b)
+	if err != nil || u != 1000 {
+		t.Error("expected 1000, got", u, err)
+	}
+
+	f, b, err := DecodeAs[bool](b)
+	if err != nil || f != true {
+		t.Error("expected true, got", f, err)
+	}
+
+	tm, b, err := DecodeAs[time.Time](b)
+	if err != nil || !tm.Equal(now) {
+		t.Error("expected", now, "got", tm, err)
+	}
+
+	bb, b, err := DecodeAs[[]byte](b)
+	if err != nil || !bytes.Equal(bb, []byte("bytes")) || len(b) != 0 {
+		t.Error("expected bytes, got", bb, b, err)
+	}
+}
+
+func TestDecodeAsNil(t *testing.T) {
+	b := MustEncode(nil, 1)
+
+	if v, next, err := DecodeAs[interface{}](b); err != nil || v != nil || !bytes.Equal(next, One) {
+		t.Error("expected nil, got", v, next, err)
+	}
+
+	if v, _, err := DecodeAs[[]byte](b); err != nil || v != nil {
+		t.Error("expected nil, got", v, err)
+	}
+
+	if v, _, err := DecodeAs[*int](b); err != nil || v != nil {
+		t.Error("expected nil, got", v, err)
+	}
+
+	if _, _, err := DecodeAs[int64](b); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := DecodeAs[string](b); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestDecodeAsMismatch(t *testing.T) {
+	if v, _, err := DecodeAs[int64](MustEncode("hello")); err != CorruptedBufferError || v != 0 {
+		t.Error("expected", CorruptedBufferError, "got", v, err)
+	}
+
+	if v, _, err := DecodeAs[string](MustEncode(1)); err != CorruptedBufferError || v != "" {
+		t.Error("expected", CorruptedBufferError, "got", v, err)
+	}
+
+	if _, _, err := DecodeAs[uint64](MustEncode(int64(-5))); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	if _, _, err := DecodeAs[int8](MustEncode(300)); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	if _, _, err := DecodeAs[uint32](MustEncode("hello")); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := DecodeAs[int64](nil); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+}
+
+func TestEncodeSlice(t *testing.T) {
+	ints := []int{-100000, -1, 0, 7, 300}
+
+	b, err := EncodeSlice(ints)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(b, MustEncode(ints)) {
+		t.Error("expected", MustEncode(ints), "got", b)
+	}
+
+	res, err := DecodeSlice[int64](b)
+	if err != nil || len(res) != len(ints) {
+		t.Fatal("expected", ints, "got", res, err)
+	}
+
+	for i, v := range res {
+		if v != int64(ints[i]) {
+			t.Error("expected", ints[i], "got", v)
+		}
+	}
+
+	strs := []string{"a", "bb", "ccc"}
+
+	b, err = EncodeSlice(strs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sres, err := DecodeSlice[string](b)
+	if err != nil || len(sres) != len(strs) {
+		t.Fatal("expected", strs, "got", sres, err)
+	}
+
+	for i, v := range sres {
+		if v != strs[i] {
+			t.Error("expected", strs[i], "got", v)
+		}
+	}
+
+	uints := []uint64{0, 16, 17, 18446744073709551615}
+
+	b, err = EncodeSlice(uints)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ures, err := DecodeSlice[uint64](b)
+	if err != nil || len(ures) != len(uints) {
+		t.Fatal("expected", uints, "got", ures, err)
+	}
+
+	for i, v := range ures {
+		if v != uints[i] {
+			t.Error("expected", uints[i], "got", v)
+		}
+	}
+
+	if _, err := DecodeSlice[uint64](MustEncode(1, -2)); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	if _, err := DecodeSlice[uint64](MustEncode(1, "2")); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, err := EncodeSlice([]struct{}{{}}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+}
+
+func TestDecodeSliceIntegers(t *testing.T) {
+	ints := []int{-100000, -1, 0, 7, 300}
+
+	b, err := EncodeSlice(ints)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := DecodeSlice[int](b)
+	if err != nil || len(res) != len(ints) {
+		t.Fatal("expected", ints, "got", res, err)
+	}
+
+	for i, v := range res {
+		if v != ints[i] {
+			t.Error("expected", ints[i], "got", v)
+		}
+	}
+
+	uints := []uint32{0, 16, 17, 65536, math.MaxUint32}
+
+	b, err = EncodeSlice(uints)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ures, err := DecodeSlice[uint32](b)
+	if err != nil || len(ures) != len(uints) {
+		t.Fatal("expected", uints, "got", ures, err)
+	}
+
+	for i, v := range ures {
+		if v != uints[i] {
+			t.Error("expected", uints[i], "got", v)
+		}
+	}
+
+	if _, err := DecodeSlice[uint32](MustEncode(uint64(math.MaxUint32) + 1)); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	n := 42
+	b, _ = EncodeNullable(&n, true)
+
+	if v, _, err := DecodeNullable[int](b); err != nil || v == nil || *v != n {
+		t.Error("expected", n, "got", v, err)
+	}
+}
+
+func TestCoerceTo(t *testing.T) {
+	v, _, _ := Decode(EncodeInt64(300))
+
+	if i, err := CoerceTo[int16](v); err != nil || i != 300 {
+		t.Error("expected 300, got", i, err)
+	}
+
+	if u, err := CoerceTo[uint32](v); err != nil || u != 300 {
+		t.Error("expected 300, got", u, err)
+	}
+
+	if _, err := CoerceTo[int8](v); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	if _, err := CoerceTo[uint8](v); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	v, _, _ = Decode(EncodeInt64(-1))
+
+	if i, err := CoerceTo[int8](v); err != nil || i != -1 {
+		t.Error("expected -1, got", i, err)
+	}
+
+	if _, err := CoerceTo[uint64](v); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	v, _, _ = Decode(EncodeUint64(18446744073709551615))
+
+	if u, err := CoerceTo[uint64](v); err != nil || u != 18446744073709551615 {
+		t.Error("expected max uint64, got", u, err)
+	}
+
+	if _, err := CoerceTo[int64](v); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	v, _, _ = Decode(EncodeUint64(200))
+
+	if _, err := CoerceTo[int8](v); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	if i, err := CoerceTo[int](v); err != nil || i != 200 {
+		t.Error("expected 200, got", i, err)
+	}
+
+	if _, err := CoerceTo[int](MustDecodeAll(MustEncode("x"))[0]); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func checkComparable[T cmp.Ordered](t *testing.T, values []T) {
+	var prev []byte
+
+	for _, v := range values {
+		b := EncodeComparable(v)
+
+		if prev != nil && bytes.Compare(prev, b) != -1 {
+			t.Errorf("%T: %v should be less than %v", v, prev, b)
+		}
+
+		prev = b
+	}
+}
+
+func TestEncodeComparable(t *testing.T) {
+	type level int8
+
+	checkComparable(t, []int{math.MinInt, -1000, -9, -8, 0, 7, 8, 1000, math.MaxInt})
+	checkComparable(t, []uint64{0, 16, 17, 1000, math.MaxUint64})
+	checkComparable(t, []float64{math.Inf(-1), -1.5, 0, 1e-300, 1.5, math.Inf(1)})
+	checkComparable(t, []string{"", "\x00", "a", "ab", "b", "ba"})
+	checkComparable(t, []level{-3, 0, 5})
+
+	if b := EncodeComparable(42); !bytes.Equal(b, EncodeInt64(42)) {
+		t.Error("expected", EncodeInt64(42), "got", b)
+	}
+
+	if b := EncodeComparable(uint8(42)); !bytes.Equal(b, EncodeUint64(42)) {
+		t.Error("expected", EncodeUint64(42), "got", b)
+	}
+
+	if b := EncodeComparable(float32(0.5)); !bytes.Equal(b, EncodeFloat64(0.5)) {
+		t.Error("expected", EncodeFloat64(0.5), "got", b)
+	}
+
+	if b := EncodeComparable("hello"); !bytes.Equal(b, EncodeBytesOrdered([]byte("hello"))) {
+		t.Error("expected", EncodeBytesOrdered([]byte("hello")), "got", b)
+	}
+
+	if v, _, _ := Decode(EncodeComparable(level(-3))); v != int64(-3) {
+		t.Error("expected -3, got", v)
+	}
+}
+
+func checkNullable[T comparable](t *testing.T, v T) {
+	for _, nilFirst := range []bool{true, false} {
+		b, err := EncodeNullable(&v, nilFirst)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := MustEncode(v); !bytes.Equal(b, expected) {
+			t.Errorf("%T: expected %v, got %v", v, expected, b)
+		}
+
+		d, next, err := DecodeNullable[T](append(b, One...))
+		if err != nil || d == nil || *d != v || !bytes.Equal(next, One) {
+			t.Errorf("%T: expected %v, got %v %v %v", v, v, d, next, err)
+		}
+
+		b, err = EncodeNullable[T](nil, nilFirst)
+		if err != nil || !bytes.Equal(b, MustEncodeNils(nilFirst, nil)) {
+			t.Errorf("%T: expected nil, got %v %v", v, b, err)
+		}
+
+		d, next, err = DecodeNullable[T](append(b, One...))
+		if err != nil || d != nil || !bytes.Equal(next, One) {
+			t.Errorf("%T: expected nil, got %v %v %v", v, d, next, err)
+		}
+	}
+}
+
+func TestEncodeNullable(t *testing.T) {
+	checkNullable(t, int64(-42))
+	checkNullable(t, uint64(1000))
+	checkNullable(t, "hello")
+	checkNullable(t, 1.5)
+	checkNullable(t, true)
+	checkNullable(t, time.Unix(1500000000, 0).UTC())
+
+	// nil first sorts before any value and nil last after
+	v := "a"
+	nf, _ := EncodeNullable[string](nil, true)
+	nl, _ := EncodeNullable[string](nil, false)
+	b, _ := EncodeNullable(&v, true)
+
+	if bytes.Compare(nf, b) != -1 || bytes.Compare(b, nl) != -1 {
+		t.Error("expected", nf, "<", b, "<", nl)
+	}
+
+	if _, err := EncodeNullable(&map[string]int{}, true); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if _, _, err := DecodeNullable[string](MustEncode(1)); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := DecodeNullable[string](nil); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+}
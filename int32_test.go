@@ -0,0 +1,22 @@
+//go:build 386 || arm || mips || mipsle
+
+package typedbuffer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecodeInt32Bit(t *testing.T) {
+	for _, i := range []int64{math.MaxInt32 + 1, math.MinInt32 - 1, 1 << 40, math.MinInt64} {
+		if _, _, err := DecodeInt(EncodeInt64(i)); err != ValueOutOfRangeError {
+			t.Error(i, "expected", ValueOutOfRangeError, "got", err)
+		}
+	}
+
+	for _, i := range []int64{math.MaxInt32, math.MinInt32} {
+		if d, _, err := DecodeInt(EncodeInt64(i)); err != nil || int64(d) != i {
+			t.Error("expected", i, "got", d, err)
+		}
+	}
+}
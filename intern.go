@@ -0,0 +1,160 @@
+package typedbuffer
+
+import (
+	"fmt"
+)
+
+//
+// An Interner encodes strings as small uint ids, assigned in the order the strings are first seen,
+// to shrink records with many repeated strings. The dictionary of strings is serialized separately
+// (see Dictionary) and used by a Deinterner to resolve the ids on decode.
+// Note that interned strings sort by id, not by content
+//
+type Interner struct {
+	ids     map[string]uint64
+	strings []string
+}
+
+//
+// Create a new, empty, Interner
+//
+func NewInterner() *Interner {
+	return &Interner{ids: map[string]uint64{}}
+}
+
+//
+// Return the id of string s, assigning a new one if s was never seen
+//
+func (in *Interner) ID(s string) uint64 {
+	if id, ok := in.ids[s]; ok {
+		return id
+	}
+
+	id := uint64(len(in.strings))
+	in.ids[s] = id
+	in.strings = append(in.strings, s)
+	return id
+}
+
+//
+// Encode values as Encode, but strings are replaced by their ids.
+// Returns an error wrapping NoEncoding if one of the values is encoded as uints (e.g. uint64 or []uint64),
+// since they couldn't be told apart from ids
+//
+func (in *Interner) Encode(values ...interface{}) ([]byte, error) {
+	b := []byte{}
+
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			b = AppendUint64(b, in.ID(s))
+			continue
+		}
+
+		start := len(b)
+
+		var err error
+		if b, err = appendValues(b, true, []interface{}{v}); err != nil {
+			return nil, err
+		}
+
+		if containsUint(b[start:]) {
+			return nil, fmt.Errorf("%w: %T values can't be mixed with interned strings", NoEncoding, v)
+		}
+	}
+
+	return b, nil
+}
+
+//
+// Return true if one of the values in typed buffer is a uint
+//
+func containsUint(b []byte) bool {
+	for len(b) > 0 {
+		if IsUint(b) {
+			return true
+		}
+
+		n, err := ValueLen(b)
+		if err != nil {
+			return false
+		}
+
+		b = b[n:]
+	}
+
+	return false
+}
+
+//
+// Return the encoded dictionary: all strings, in the order of their ids
+//
+func (in *Interner) Dictionary() []byte {
+	b := []byte{}
+
+	for _, s := range in.strings {
+		b = appendString(b, s)
+	}
+
+	return b
+}
+
+//
+// A Deinterner resolves the ids of strings encoded by an Interner
+//
+type Deinterner struct {
+	strings []string
+}
+
+//
+// Create a new Deinterner from a dictionary returned by Interner.Dictionary
+//
+func NewDeinterner(dict []byte) (*Deinterner, error) {
+	values, err := DecodeAll(true, dict)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Deinterner{strings: make([]string, len(values))}
+
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, CorruptedBufferError
+		}
+
+		d.strings[i] = s
+	}
+
+	return d, nil
+}
+
+//
+// Return the string with the given id, or ValueOutOfRangeError if the id is not in the dictionary
+//
+func (d *Deinterner) String(id uint64) (string, error) {
+	if id >= uint64(len(d.strings)) {
+		return "", ValueOutOfRangeError
+	}
+
+	return d.strings[id], nil
+}
+
+//
+// Decode all values in a typed buffer encoded by Interner.Encode, resolving ids to strings
+//
+func (d *Deinterner) DecodeAll(b []byte) ([]interface{}, error) {
+	values, err := DecodeAll(false, b)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range values {
+		if id, ok := v.(uint64); ok {
+			if values[i], err = d.String(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return values, nil
+}
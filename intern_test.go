@@ -0,0 +1,93 @@
+package typedbuffer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInterner(t *testing.T) {
+	colors := []string{"green", "blue", "green", "red", "blue", "green", "green", "red"}
+
+	in := NewInterner()
+
+	var records [][]byte
+	interned, plain := 0, 0
+
+	for i, c := range colors {
+		b, err := in.Encode(i, c, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records = append(records, b)
+		interned += len(b)
+		plain += len(MustEncode(i, c, nil))
+	}
+
+	dict := in.Dictionary()
+	t.Log("plain:", plain, "interned:", interned, "dictionary:", len(dict))
+
+	if interned+len(dict) >= plain {
+		t.Error("expected interned records and dictionary to be smaller than", plain, "got", interned+len(dict))
+	}
+
+	if id := in.ID("blue"); id != 1 {
+		t.Error("expected id 1, got", id)
+	}
+
+	d, err := NewDeinterner(dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, b := range records {
+		values, err := d.DecodeAll(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(values) != 3 || values[0] != int64(i) || values[1] != colors[i] || values[2] != nil {
+			t.Error("expected", i, colors[i], "got", values)
+		}
+	}
+
+	if s, err := d.String(2); err != nil || s != "red" {
+		t.Error("expected red, got", s, err)
+	}
+
+	if _, err := d.String(3); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	if _, err := d.DecodeAll(EncodeUint64(10)); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+}
+
+func TestInternerErrors(t *testing.T) {
+	in := NewInterner()
+
+	if _, err := in.Encode("a", uint64(1)); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if _, err := in.Encode("a", []uint64{1, 2}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if _, err := in.Encode("a", uint8(1)); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if _, err := in.Encode("a", map[string]int{}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if _, err := NewDeinterner(MustEncode("a", 1)); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, err := NewDeinterner([]byte{0x01}); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
@@ -0,0 +1,40 @@
+package typedbuffer
+
+import (
+	"net"
+)
+
+//
+// Encode IP address. IPv4 addresses (including IPv4-mapped IPv6 addresses) are encoded
+// in 4-byte form and sort before IPv6 addresses, encoded in 16-byte form.
+// Returns nil if ip is not a valid IP address
+//
+func EncodeIP(ip net.IP) []byte {
+	return appendIP(nil, ip)
+}
+
+//
+// Append encoded IP address to dst. Returns nil if ip is not a valid IP address
+//
+func appendIP(dst []byte, ip net.IP) []byte {
+	if ip4 := ip.To4(); ip4 != nil {
+		return append(append(dst, BB_IPV4), ip4...)
+	}
+
+	if len(ip) == net.IPv6len {
+		return append(append(dst, BB_IPV6), ip...)
+	}
+
+	return nil
+}
+
+//
+// Return the length of the address following tag k (BB_IPV4 or BB_IPV6)
+//
+func payloadLenIP(k byte) int {
+	if k == BB_IPV4 {
+		return net.IPv4len
+	}
+
+	return net.IPv6len
+}
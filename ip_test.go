@@ -0,0 +1,82 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestEncodeIP(t *testing.T) {
+	tests := []CompareItem{
+		{EncodeIP(net.ParseIP("10.0.0.1")), EncodeIP(net.ParseIP("10.0.0.2"))},
+		{EncodeIP(net.ParseIP("9.255.255.255")), EncodeIP(net.ParseIP("10.0.0.0"))},
+		{EncodeIP(net.ParseIP("2001:db8::1")), EncodeIP(net.ParseIP("2001:db8::2"))},
+		{EncodeIP(net.ParseIP("255.255.255.255")), EncodeIP(net.ParseIP("::1"))},
+	}
+
+	for _, tt := range tests {
+		if bytes.Compare(tt.min, tt.max) != -1 || Compare(tt.min, tt.max) != -1 {
+			t.Error(tt.min, "should be less than", tt.max)
+		}
+	}
+
+	for _, s := range []string{"10.0.0.1", "::ffff:10.0.0.1", "2001:db8::1", "::"} {
+		ip := net.ParseIP(s)
+		b := EncodeIP(ip)
+
+		if eb := MustEncode(ip); !bytes.Equal(eb, b) {
+			t.Error(s, "expected", b, "got", eb)
+		}
+
+		if l, _ := EncodedLen(ip); l != len(b) {
+			t.Error(s, "expected length", len(b), "got", l)
+		}
+
+		v, err := DecodeOne(b)
+		if err != nil {
+			t.Fatal(s, err)
+		}
+
+		if dip, ok := v.(net.IP); !ok || !dip.Equal(ip) {
+			t.Errorf("%v: expected %v, got %T %v", s, ip, v, v)
+		}
+
+		if k, _ := TypeOf(b); k != IP {
+			t.Error("expected", IP, "got", k)
+		}
+	}
+
+	if len(EncodeIP(net.ParseIP("10.0.0.1"))) != 5 {
+		t.Error("expected IPv4 address in 4-byte form")
+	}
+
+	if b := EncodeIP(net.IP{1, 2, 3}); b != nil {
+		t.Error("expected nil, got", b)
+	}
+
+	if _, err := Encode(net.IP{1, 2, 3}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if b := MustEncode(net.IP(nil)); !bytes.Equal(b, NilFirst) {
+		t.Error("expected", NilFirst, "got", b)
+	}
+}
+
+func TestEncodeStructIP(t *testing.T) {
+	type host struct {
+		Name string
+		Addr net.IP
+	}
+
+	b, err := EncodeStruct(host{"localhost", net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var h host
+	if err := DecodeStruct(b, &h); err != nil || !h.Addr.Equal(net.ParseIP("127.0.0.1")) {
+		t.Error("unexpected", h, err)
+	}
+}
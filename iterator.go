@@ -0,0 +1,77 @@
+package typedbuffer
+
+//
+// An Iterator decodes the values in a typed buffer one at a time:
+//
+//	it := NewIterator(b)
+//	for it.Next() {
+//	    v := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//	    ...
+//	}
+//
+type Iterator struct {
+	b   []byte
+	off int
+	v   interface{}
+	err error
+}
+
+//
+// Create a new Iterator over typed buffer b
+//
+func NewIterator(b []byte) *Iterator {
+	return &Iterator{b: b}
+}
+
+//
+// Decode the next value. Returns false at the end of the buffer or if an error occurred
+//
+func (it *Iterator) Next() bool {
+	if it.err != nil || len(it.b) == 0 {
+		it.v = nil
+		return false
+	}
+
+	v, next, err := Decode(it.b)
+	if err != nil {
+		it.v, it.err = nil, withOffset(err, it.off)
+		return false
+	}
+
+	it.v = v
+	it.off += len(it.b) - len(next)
+	it.b = next
+	return true
+}
+
+//
+// Return the current value
+//
+func (it *Iterator) Value() interface{} {
+	return it.v
+}
+
+//
+// Return the error that stopped the iteration, if any
+//
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+//
+// Decode the values in typed buffer b one at a time, calling fn for each of them.
+// Stops and returns the error if fn returns an error, or if a value can't be decoded
+//
+func DecodeEach(b []byte, fn func(v interface{}) error) error {
+	it := NewIterator(b)
+
+	for it.Next() {
+		if err := fn(it.Value()); err != nil {
+			return err
+		}
+	}
+
+	return it.Err()
+}
@@ -0,0 +1,104 @@
+package typedbuffer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIterator(t *testing.T) {
+	b := MustEncode(10, "hello", -100000, false, nil, uint64(1000))
+	expected := MustDecodeAll(b)
+
+	it := NewIterator(b)
+
+	i := 0
+	for it.Next() {
+		v := it.Value()
+		if sb, ok := v.([]byte); ok {
+			v = string(sb)
+		}
+
+		if i >= len(expected) || v != expected[i] {
+			t.Error("unexpected value", v, "at", i)
+		}
+
+		i++
+	}
+
+	if it.Err() != nil {
+		t.Error("unexpected error", it.Err())
+	}
+
+	if i != len(expected) {
+		t.Error("expected", len(expected), "values, got", i)
+	}
+
+	if it.Next() {
+		t.Error("Next should return false at the end of the buffer")
+	}
+}
+
+func TestIteratorError(t *testing.T) {
+	b := MustEncode(10, "hello")
+
+	it := NewIterator(b[:len(b)-1])
+
+	if !it.Next() || it.Value() != int64(10) {
+		t.Fatal("expected 10, got", it.Value())
+	}
+
+	if it.Next() {
+		t.Error("Next should fail on truncated buffer")
+	}
+
+	var de *DecodeError
+	if !errors.As(it.Err(), &de) || de.Offset != 2 {
+		t.Error("expected decode error at offset 2, got", it.Err())
+	}
+}
+
+func TestDecodeEach(t *testing.T) {
+	var b []byte
+	for i := 1; i <= 100; i++ {
+		b = AppendInt64(b, int64(i))
+	}
+
+	sum := int64(0)
+
+	err := DecodeEach(b, func(v interface{}) error {
+		sum += v.(int64)
+		return nil
+	})
+
+	if err != nil || sum != 5050 {
+		t.Error("expected 5050, got", sum, err)
+	}
+
+	// abort early
+	stop := errors.New("stop")
+	count := 0
+
+	err = DecodeEach(b, func(v interface{}) error {
+		count++
+		if v == int64(10) {
+			return stop
+		}
+		return nil
+	})
+
+	if err != stop || count != 10 {
+		t.Error("expected to stop after 10 values, got", count, err)
+	}
+
+	// decoding errors are returned, after the values decoded before the error
+	count = 0
+
+	err = DecodeEach(append(MustEncode(1, 2), 0x01), func(v interface{}) error {
+		count++
+		return nil
+	})
+
+	if !errors.Is(err, CorruptedBufferError) || count != 2 {
+		t.Error("expected", CorruptedBufferError, "after 2 values, got", count, err)
+	}
+}
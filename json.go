@@ -0,0 +1,89 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"unicode/utf8"
+)
+
+//
+// Decode all values in a typed buffer as a JSON array.
+// Byte slices are rendered as strings if they are valid UTF-8, otherwise as {"base64": "..."} objects.
+// Dates are rendered in RFC 3339 format, and NaN and infinite doubles as the strings "NaN", "+Inf" and "-Inf"
+// (that JSON numbers can't represent).
+//
+func DecodeToJSON(b []byte) ([]byte, error) {
+	values, err := DecodeAll(false, b)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range values {
+		switch t := v.(type) {
+		case []byte:
+			if utf8.Valid(t) {
+				values[i] = string(t)
+			} else {
+				values[i] = map[string]string{"base64": base64.StdEncoding.EncodeToString(t)}
+			}
+
+		case float64:
+			if math.IsNaN(t) || math.IsInf(t, 0) {
+				values[i] = strconv.FormatFloat(t, 'g', -1, 64)
+			}
+		}
+	}
+
+	return json.Marshal(values)
+}
+
+//
+// Encode the values in a JSON array.
+// Numbers are encoded as int64 if possible, then uint64, then big.Int, then float64.
+// {"base64": "..."} objects (see DecodeToJSON) are encoded as byte slices.
+//
+func EncodeFromJSON(j []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.UseNumber()
+
+	var values []interface{}
+	if err := dec.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	for i, v := range values {
+		switch t := v.(type) {
+		case json.Number:
+			if iv, err := strconv.ParseInt(t.String(), 10, 64); err == nil {
+				values[i] = iv
+			} else if uv, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+				values[i] = uv
+			} else if bv, ok := new(big.Int).SetString(t.String(), 10); ok {
+				values[i] = bv
+			} else if fv, err := t.Float64(); err == nil {
+				values[i] = fv
+			} else {
+				return nil, NoEncoding
+			}
+
+		case map[string]interface{}:
+			s, ok := t["base64"].(string)
+			if !ok || len(t) != 1 {
+				return nil, NoEncoding
+			}
+
+			bb, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, err
+			}
+
+			values[i] = bb
+		}
+	}
+
+	return Encode(values...)
+}
@@ -0,0 +1,81 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	b := MustEncode(10, "hello", -100000, false, nil, uint64(18446744073709551615), "")
+
+	j, err := DecodeToJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `[10,"hello",-100000,false,null,18446744073709551615,""]`; string(j) != expected {
+		t.Error("expected", expected, "got", string(j))
+	}
+
+	eb, err := EncodeFromJSON(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(eb, b) {
+		t.Error("expected", b, "got", eb)
+	}
+
+	big := []byte(`[-100000000000000000000000,100000000000000000000000]`)
+
+	if b, err := EncodeFromJSON(big); err != nil {
+		t.Error(err)
+	} else if j, _ := DecodeToJSON(b); !bytes.Equal(j, big) {
+		t.Error("expected", string(big), "got", string(j))
+	}
+
+	if _, err := EncodeFromJSON([]byte(`[{"a": 1}]`)); err != NoEncoding {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if _, err := EncodeFromJSON([]byte(`{"a": 1}`)); err == nil {
+		t.Error("expected error encoding a JSON object")
+	}
+}
+
+func TestJSONFloatsAndBytes(t *testing.T) {
+	b := MustEncode(1.5, -0.25, []byte{0xff, 0x00, 0x01}, "text")
+
+	j, err := DecodeToJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `[1.5,-0.25,{"base64":"/wAB"},"text"]`; string(j) != expected {
+		t.Error("expected", expected, "got", string(j))
+	}
+
+	eb, err := EncodeFromJSON(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(eb, b) {
+		t.Error("expected", b, "got", eb)
+	}
+
+	// NaN and infinities have no JSON number representation
+	j, err = DecodeToJSON(MustEncode(math.NaN(), math.Inf(1), math.Inf(-1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `["NaN","+Inf","-Inf"]`; string(j) != expected {
+		t.Error("expected", expected, "got", string(j))
+	}
+
+	if _, err := EncodeFromJSON([]byte(`[{"base64": "!"}]`)); err == nil {
+		t.Error("expected error decoding invalid base64")
+	}
+}
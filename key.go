@@ -0,0 +1,95 @@
+package typedbuffer
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+//
+// A Key is an encoded typed buffer that can be stored in (and read from) a SQL BLOB column
+//
+type Key []byte
+
+//
+// Create a new Key encoding the specified values
+//
+func NewKey(values ...interface{}) (Key, error) {
+	b, err := Encode(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return Key(b), nil
+}
+
+//
+// Encode values as a composite key that sorts with bytes.Compare. Values are encoded as with Encode,
+// but byte slices and strings (including the elements of []string and [][]byte) are encoded
+// as ordered bytes (see EncodeBytesOrdered),
+// so that a value sorts before longer values with the same prefix, i.e. (1, "dog") < (1, "dogs") < (2, "a").
+// The key can be decoded with Decode (or DecodeAll)
+//
+func EncodeKey(values ...interface{}) ([]byte, error) {
+	b := []byte{}
+
+	for _, v := range values {
+		var err error
+
+		switch t := v.(type) {
+		case []byte:
+			b = appendBytesOrdered(b, t)
+
+		case string:
+			b = appendBytesOrdered(b, []byte(t))
+
+		case []string:
+			for _, s := range t {
+				b = appendBytesOrdered(b, []byte(s))
+			}
+
+		case [][]byte:
+			for _, bb := range t {
+				b = appendBytesOrdered(b, bb)
+			}
+
+		default:
+			if b, err = appendValues(b, true, []interface{}{v}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return b, nil
+}
+
+//
+// Implements driver.Valuer, returning the encoded bytes
+//
+func (k Key) Value() (driver.Value, error) {
+	if k == nil {
+		return nil, nil
+	}
+
+	return []byte(k), nil
+}
+
+//
+// Implements sql.Scanner, accepting []byte, string or nil values
+//
+func (k *Key) Scan(src interface{}) error {
+	switch t := src.(type) {
+	case nil:
+		*k = nil
+
+	case []byte:
+		*k = append(Key{}, t...)
+
+	case string:
+		*k = Key(t)
+
+	default:
+		return fmt.Errorf("typedbuffer: cannot scan %T into Key", src)
+	}
+
+	return nil
+}
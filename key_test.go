@@ -0,0 +1,164 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+var (
+	_ driver.Valuer = Key{}
+	_ sql.Scanner   = &Key{}
+)
+
+func MustEncodeKey(values ...interface{}) []byte {
+	b, err := EncodeKey(values...)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestKey(t *testing.T) {
+	k, err := NewKey(1, "hello", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// what a driver would store
+	var dv driver.Valuer = k
+
+	v, err := dv.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, ok := v.([]byte)
+	if !ok || !bytes.Equal(stored, MustEncode(1, "hello", true)) {
+		t.Fatal("unexpected value", v)
+	}
+
+	// what a driver would return
+	var scanned Key
+	var sc sql.Scanner = &scanned
+
+	for _, src := range []interface{}{stored, string(stored)} {
+		if err := sc.Scan(src); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(scanned, k) {
+			t.Error("expected", k, "got", scanned)
+		}
+	}
+
+	if err := sc.Scan(stored); err != nil {
+		t.Fatal(err)
+	}
+
+	stored[0] = 0xFF
+	if bytes.Equal(scanned, stored) {
+		t.Error("scanned key should not alias the source buffer")
+	}
+
+	if err := sc.Scan(nil); err != nil || scanned != nil {
+		t.Error("expected nil key, got", scanned, err)
+	}
+
+	if v, _ := scanned.Value(); v != nil {
+		t.Error("expected nil value, got", v)
+	}
+
+	if err := sc.Scan(42); err == nil {
+		t.Error("expected error scanning an int")
+	}
+
+	if _, err := NewKey(struct{}{}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+}
+
+func TestEncodeKey(t *testing.T) {
+	keys := [][]interface{}{
+		{1, ""},
+		{1, "dog"},
+		{1, "dog\x00"},
+		{1, "dogs"},
+		{1, []byte("dogs\xff")},
+		{2, "a"},
+		{2, "a", 1},
+		{2, "ab"},
+		{1000, nil},
+		{1000, "a"},
+	}
+
+	var prev []byte
+
+	for _, values := range keys {
+		b, err := EncodeKey(values...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if prev != nil && bytes.Compare(prev, b) != -1 {
+			t.Error(MustDecodeAll(prev), "should be less than", values)
+		}
+
+		decoded, err := DecodeAll(false, b)
+		if err != nil || len(decoded) != len(values) {
+			t.Fatal("expected", values, "got", decoded, err)
+		}
+
+		for i, v := range decoded {
+			expected := values[i]
+			if s, ok := expected.(string); ok {
+				expected = []byte(s)
+			}
+
+			if eb, ok := expected.([]byte); ok {
+				if !bytes.Equal(v.([]byte), eb) {
+					t.Error("expected", eb, "got", v)
+				}
+			} else if expected != nil && v != int64(expected.(int)) {
+				t.Error("expected", expected, "got", v)
+			}
+		}
+
+		prev = b
+	}
+
+	// with Encode a longer string sorts after a shorter one, whatever their content
+	if bytes.Compare(MustEncode(1, "dogs"), MustEncode(1, "e")) != 1 {
+		t.Error("expected the length prefixed encoding to sort by length")
+	}
+
+	// slices of strings and bytes are expanded into ordered bytes elements
+	sk := [][]byte{}
+	for _, values := range [][]interface{}{
+		{1, []string{"dog"}},
+		{1, [][]byte{[]byte("dog"), []byte("a")}},
+		{1, []string{"dogs"}},
+		{1, []string{"e"}},
+	} {
+		b, err := EncodeKey(values...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n := len(sk); n > 0 && bytes.Compare(sk[n-1], b) != -1 {
+			t.Error(sk[n-1], "should be less than", b)
+		}
+
+		sk = append(sk, b)
+	}
+
+	if b, _ := EncodeKey(1, []string{"a", "b"}); !bytes.Equal(b, MustEncodeKey(1, "a", "b")) {
+		t.Error("expected []string to be encoded as its elements, got", b)
+	}
+
+	if _, err := EncodeKey(1, map[string]int{}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+}
@@ -0,0 +1,177 @@
+package typedbuffer
+
+//
+// The logical type of an encoded value
+//
+type Kind int
+
+const (
+	Invalid Kind = iota
+	Nil
+	Bool
+	Bytes
+	Int
+	Uint
+	Double
+	Date
+	IP
+	UUID
+	Bools
+	Enum
+	Duration
+	String // slice of bytes decoded as a string (TypeOf returns Bytes, see Decoder.Schema)
+	Rat
+)
+
+var kindNames = []string{
+	Invalid:  "invalid",
+	Nil:      "nil",
+	Bool:     "bool",
+	Bytes:    "bytes",
+	Int:      "int",
+	Uint:     "uint",
+	Double:   "double",
+	Date:     "date",
+	IP:       "ip",
+	UUID:     "uuid",
+	Bools:    "bools",
+	Enum:     "enum",
+	Duration: "duration",
+	String:   "string",
+	Rat:      "rat",
+}
+
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+
+	return "invalid"
+}
+
+//
+// Return the kind of a value from its type tag
+//
+func kindOf(k byte) Kind {
+	switch {
+	case k == BB_NIL_FIRST || k == BB_NIL_LAST:
+		return Nil
+
+	case k == BB_BOOLEAN_FALSE || k == BB_BOOLEAN_TRUE:
+		return Bool
+
+	case k == BB_BOOLEAN_ARRAY:
+		return Bools
+
+	case k >= BB_BYTES && k <= BB_BYTES_LEN_4, k == BB_BYTES_ORDERED:
+		return Bytes
+
+	case k == BB_IPV4 || k == BB_IPV6:
+		return IP
+
+	case k == BB_UUID:
+		return UUID
+
+	case k == BB_ENUM:
+		return Enum
+
+	case k == BB_DURATION:
+		return Duration
+
+	case k == BB_RAT:
+		return Rat
+
+	case k == BB_DATE || k == BB_DATE_UNIT:
+		return Date
+
+	case (k&BB_DATE_MASK) == BB_POSITIVE_DATE || (k&BB_DATE_MASK) == BB_NEGATIVE_DATE:
+		return Date
+
+	case (k&BB_DATE_MASK) == BB_LEGACY_DATE && k != BB_LEGACY_DATE:
+		return Date
+
+	case (k&BB_DATE_MASK) == BB_POSITIVE_DATE_MS || (k&BB_DATE_MASK) == BB_NEGATIVE_DATE_MS:
+		return Date
+
+	case k >= BB_DOUBLE_NEGATIVE_INFINITY && k <= BB_DOUBLE_NEGATIVE_ZERO:
+		return Double
+
+	case k >= BB_DOUBLE_POSITIVE_ZERO && k <= BB_DOUBLE_NAN:
+		return Double
+
+	case k >= BB_INT_NEGATIVE_VALUE && k <= MAX_SMALL_NEGATIVE:
+		return Int
+
+	case k >= MIN_SMALL_POSITIVE && k <= MAX_INT_POSITIVE_VALUE:
+		return Int
+
+	case k == BB_BIG_POSITIVE || k == BB_BIG_NEGATIVE:
+		return Int
+
+	case k >= MIN_SMALL_UINT && k <= MAX_UINT_VAR:
+		return Uint
+
+	default:
+		return Invalid
+	}
+}
+
+//
+// Return the kind of the first value in a typed buffer, looking only at the type tag
+//
+func TypeOf(b []byte) (Kind, error) {
+	if len(b) == 0 {
+		return Invalid, EmptyBufferError
+	}
+
+	k := b[0]
+	if k == BB_DESC && len(b) > 1 {
+		k = ^b[1]
+	}
+
+	if k := kindOf(k); k != Invalid {
+		return k, nil
+	}
+
+	return Invalid, CorruptedBufferError
+}
+
+//
+// Return true if the first value in typed buffer is nil
+//
+func IsNil(b []byte) bool {
+	k, err := TypeOf(b)
+	return err == nil && k == Nil
+}
+
+//
+// Return true if the first value in typed buffer is a slice of bytes (or string)
+//
+func IsBytes(b []byte) bool {
+	k, err := TypeOf(b)
+	return err == nil && k == Bytes
+}
+
+//
+// Return true if the first value in typed buffer is a signed integer
+//
+func IsInt(b []byte) bool {
+	k, err := TypeOf(b)
+	return err == nil && k == Int
+}
+
+//
+// Return true if the first value in typed buffer is an unsigned integer
+//
+func IsUint(b []byte) bool {
+	k, err := TypeOf(b)
+	return err == nil && k == Uint
+}
+
+//
+// Return true if the first value in typed buffer is a boolean
+//
+func IsBool(b []byte) bool {
+	k, err := TypeOf(b)
+	return err == nil && k == Bool
+}
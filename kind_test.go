@@ -0,0 +1,95 @@
+package typedbuffer
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestTypeOf(t *testing.T) {
+	tests := []struct {
+		b    []byte
+		kind Kind
+	}{
+		{NilFirst, Nil},
+		{NilLast, Nil},
+		{True, Bool},
+		{False, Bool},
+		{EncodeBytes([]byte{}), Bytes},
+		{EncodeBytes([]byte("hello")), Bytes},
+		{EncodeBytes(make([]byte, 100)), Bytes},
+		{EncodeBytes(make([]byte, 1000)), Bytes},
+		{EncodeInt64(0), Int},
+		{EncodeInt64(7), Int},
+		{EncodeInt64(-8), Int},
+		{EncodeInt64(1000), Int},
+		{EncodeInt64(-1000), Int},
+		{EncodeInt64(9223372036854775807), Int},
+		{EncodeInt64(-9223372036854775808), Int},
+		{EncodeUint64(0), Uint},
+		{EncodeUint64(16), Uint},
+		{EncodeUint64(17), Uint},
+		{EncodeUint64(18446744073709551615), Uint},
+		{EncodeTime(time.Now()), Date},
+		{MustEncodeDesc(1000), Int},
+		{MustEncodeDesc("hello"), Bytes},
+		{EncodeBigInt(new(big.Int).Lsh(big.NewInt(1), 100)), Int},
+		{EncodeBigInt(new(big.Int).Lsh(big.NewInt(-1), 100)), Int},
+		{[]byte{BB_DOUBLE_NAN}, Double},
+		{[]byte{BB_BOOLEAN_ARRAY}, Bools},
+		{[]byte{BB_DOUBLE_POSITIVE_ZERO}, Double},
+		{[]byte{BB_DOUBLE_NEGATIVE_ZERO}, Double},
+		{[]byte{BB_DOUBLE_NEGATIVE_INFINITY}, Double},
+	}
+
+	for _, tt := range tests {
+		k, err := TypeOf(tt.b)
+		if err != nil {
+			t.Error(tt.b, err)
+		} else if k != tt.kind {
+			t.Error(tt.b, "expected", tt.kind, "got", k)
+		}
+	}
+
+	if _, err := TypeOf([]byte{}); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+
+	for _, b := range []byte{0x01, 0x0A, 0x73, 0x7F, 0x99, 0xA0, 0xF4, 0xFD} {
+		if _, err := TypeOf([]byte{b}); err != CorruptedBufferError {
+			t.Errorf("%02x: expected %v, got %v", b, CorruptedBufferError, err)
+		}
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	tests := []struct {
+		b                                     []byte
+		isNil, isBytes, isInt, isUint, isBool bool
+	}{
+		{nil, false, false, false, false, false},
+		{[]byte{}, false, false, false, false, false},
+		{[]byte{0x01}, false, false, false, false, false},
+		{NilFirst, true, false, false, false, false},
+		{NilLast, true, false, false, false, false},
+		{EncodeString("hello"), false, true, false, false, false},
+		{EncodeBytesOrdered([]byte("hello")), false, true, false, false, false},
+		{EncodeInt64(-1000), false, false, true, false, false},
+		{EncodeInt64(3), false, false, true, false, false},
+		{MustEncode(new(big.Int).Lsh(big.NewInt(1), 100)), false, false, true, false, false},
+		{EncodeUint64(1000), false, false, false, true, false},
+		{True, false, false, false, false, true},
+		{False, false, false, false, false, true},
+		{MustEncodeDesc(nil), true, false, false, false, false},
+		{MustEncodeDesc(1000), false, false, true, false, false},
+		{EncodeFloat64(1.5), false, false, false, false, false},
+		{EncodeTime(time.Now()), false, false, false, false, false},
+	}
+
+	for _, tt := range tests {
+		if IsNil(tt.b) != tt.isNil || IsBytes(tt.b) != tt.isBytes || IsInt(tt.b) != tt.isInt ||
+			IsUint(tt.b) != tt.isUint || IsBool(tt.b) != tt.isBool {
+			t.Error(tt.b, "unexpected", IsNil(tt.b), IsBytes(tt.b), IsInt(tt.b), IsUint(tt.b), IsBool(tt.b))
+		}
+	}
+}
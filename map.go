@@ -0,0 +1,84 @@
+package typedbuffer
+
+import (
+	"fmt"
+	"sort"
+)
+
+//
+// Encode a map as the number of entries followed by key/value pairs.
+// Keys are sorted, so that the same map always produces the same encoding.
+// Values must encode as a single value: slices (other than []byte and []bool) return an error wrapping NoEncoding
+//
+func EncodeMap(m map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	b := EncodeUint64(uint64(len(m)))
+
+	for _, k := range keys {
+		if err := checkBytesLen(len(k)); err != nil {
+			return nil, err
+		}
+		b = appendString(b, k)
+
+		start := len(b)
+
+		var err error
+		if b, err = appendValues(b, true, []interface{}{m[k]}); err != nil {
+			return nil, err
+		}
+
+		// slices would be encoded as several values
+		if n, err := countValues(b[start:]); err != nil || n != 1 {
+			return nil, fmt.Errorf("%w: key %q: %T is not a single value", NoEncoding, k, m[k])
+		}
+	}
+
+	return b, nil
+}
+
+//
+// Decode a map encoded with EncodeMap (byte slices are converted to strings)
+//
+func DecodeMap(b []byte) (map[string]interface{}, error) {
+	v, b, err := Decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := v.(uint64)
+	if !ok || n > uint64(len(b)) { // each entry takes at least 2 bytes
+		return nil, CorruptedBufferError
+	}
+
+	m := make(map[string]interface{}, n)
+
+	for i := uint64(0); i < n; i++ {
+		var k string
+
+		if k, b, err = DecodeString(b); err != nil {
+			return nil, err
+		}
+
+		if v, b, err = Decode(b); err != nil {
+			return nil, err
+		}
+
+		if sb, ok := v.([]byte); ok {
+			v = string(sb)
+		}
+
+		m[k] = v
+	}
+
+	if len(b) > 0 {
+		return nil, TrailingBytesError
+	}
+
+	return m, nil
+}
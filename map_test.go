@@ -0,0 +1,77 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncodeMap(t *testing.T) {
+	m := map[string]interface{}{
+		"name":  "gobs",
+		"count": 42,
+		"large": uint64(1000000),
+		"ok":    true,
+		"none":  nil,
+		"":      -1,
+	}
+
+	b, err := EncodeMap(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if bb, _ := EncodeMap(m); !bytes.Equal(b, bb) {
+			t.Fatal("encoding should be deterministic", b, bb)
+		}
+	}
+
+	d, err := DecodeMap(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"name":  "gobs",
+		"count": int64(42),
+		"large": uint64(1000000),
+		"ok":    true,
+		"none":  nil,
+		"":      int64(-1),
+	}
+
+	if len(d) != len(expected) {
+		t.Fatal("expected", expected, "got", d)
+	}
+
+	for k, v := range expected {
+		if dv, ok := d[k]; !ok || dv != v {
+			t.Errorf("%q: expected %v, got %v", k, v, dv)
+		}
+	}
+
+	if _, err := EncodeMap(map[string]interface{}{"x": struct{}{}}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if _, err := EncodeMap(map[string]interface{}{"a": []string{"x", "y"}}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if _, err := EncodeMap(map[string]interface{}{"a": []int64{}}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if _, err := DecodeMap(b[:len(b)-1]); err == nil {
+		t.Error("expected error decoding truncated map")
+	}
+
+	if _, err := DecodeMap(append(b, One...)); err != TrailingBytesError {
+		t.Error("expected", TrailingBytesError, "got", err)
+	}
+
+	if d, err := DecodeMap(MustEncode(uint64(0))); err != nil || len(d) != 0 {
+		t.Error("expected empty map, got", d, err)
+	}
+}
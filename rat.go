@@ -0,0 +1,128 @@
+package typedbuffer
+
+import (
+	"math/big"
+)
+
+//
+// Encode rational number r as its numerator and denominator (encoded as integers, see EncodeBigInt)
+// with a dedicated tag. This is for exact round trip only: encoded rationals don't sort
+// according to their value with bytes.Compare (they do with Compare)
+//
+func EncodeRat(r *big.Rat) []byte {
+	return appendRat(nil, r)
+}
+
+//
+// Append encoded rational number r to dst
+//
+func appendRat(dst []byte, r *big.Rat) []byte {
+	dst = append(dst, BB_RAT)
+	dst = appendBigInt(dst, r.Num())
+	return appendBigInt(dst, r.Denom())
+}
+
+//
+// Decode first value in typed buffer as a rational number. Returns the decoded value (normalized)
+// and the remaining buffer, or CorruptedBufferError if the value is not a rational number
+//
+func DecodeRat(b []byte) (*big.Rat, []byte, error) {
+	v, next, err := Decode(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, ok := v.(*big.Rat)
+	if !ok {
+		return nil, nil, CorruptedBufferError
+	}
+
+	return r, next, nil
+}
+
+//
+// Decode numerator and denominator of a rational number (b is the buffer after the tag).
+// Returns decoded value and remaining buffer
+//
+func decodeRat(b []byte) (interface{}, []byte, error) {
+	num, next, err := decodeRatPart(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	denom, next, err := decodeRatPart(next)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if denom.Sign() <= 0 {
+		return nil, nil, CorruptedBufferError
+	}
+
+	return new(big.Rat).SetFrac(num, denom), next, nil
+}
+
+//
+// Decode an integer part of a rational number as a big.Int
+//
+func decodeRatPart(b []byte) (*big.Int, []byte, error) {
+	if len(b) == 0 || kindOf(b[0]) != Int {
+		return nil, nil, CorruptedBufferError
+	}
+
+	v, next, err := decode(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch t := v.(type) {
+	case int64:
+		return big.NewInt(t), next, nil
+
+	case *big.Int:
+		return t, next, nil
+
+	default:
+		return nil, nil, CorruptedBufferError
+	}
+}
+
+//
+// Return the length of an encoded rational number, where all bytes are xor'ed with inv (see valueLen).
+// The denominator is decoded, to check that it's positive as decodeRat does
+//
+func ratLen(b []byte, inv byte) (int, error) {
+	n := 1
+
+	var denom []byte
+
+	for i := 0; i < 2; i++ {
+		// check the kind first, to avoid recursing on nested values
+		if len(b) <= n || kindOf(b[n]^inv) != Int {
+			return 0, CorruptedBufferError
+		}
+
+		l, err := valueLen(b[n:], inv)
+		if err != nil {
+			return 0, err
+		}
+
+		denom = b[n : n+l]
+		n += l
+	}
+
+	if inv != 0 {
+		inverted := make([]byte, len(denom))
+		for i, c := range denom {
+			inverted[i] = c ^ inv
+		}
+
+		denom = inverted
+	}
+
+	if d, _, err := decodeRatPart(denom); err != nil || d.Sign() <= 0 {
+		return 0, CorruptedBufferError
+	}
+
+	return n, nil
+}
@@ -0,0 +1,118 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestEncodeRat(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	rats := []*big.Rat{
+		big.NewRat(1, 3),
+		big.NewRat(-2, 7),
+		big.NewRat(0, 1),
+		big.NewRat(5, 1),
+		big.NewRat(-5, 1),
+		big.NewRat(6, 4),
+		big.NewRat(-1, 1<<62),
+		new(big.Rat).SetFrac(huge, big.NewInt(7)),
+		new(big.Rat).SetFrac(new(big.Int).Neg(huge), new(big.Int).Add(huge, big.NewInt(2))),
+	}
+
+	for _, r := range rats {
+		b := EncodeRat(r)
+
+		if !bytes.Equal(b, MustEncode(r)) {
+			t.Error(r, "unexpected encoding", b)
+		}
+
+		if l, err := EncodedLen(r); err != nil || l != len(b) {
+			t.Error(r, "expected length", len(b), "got", l, err)
+		}
+
+		if k, err := TypeOf(b); err != nil || k != Rat {
+			t.Error(r, "expected kind", Rat, "got", k, err)
+		}
+
+		d, next, err := DecodeRat(b)
+		if err != nil || len(next) != 0 {
+			t.Error(r, "decode error", err, next)
+			continue
+		}
+
+		if d.Cmp(r) != 0 {
+			t.Error(r, "decoded as", d)
+		}
+
+		if l, err := ValueLen(b); err != nil || l != len(b) {
+			t.Error(r, "expected value length", len(b), "got", l, err)
+		}
+
+		t.Log(r, b)
+	}
+}
+
+func TestDecodeRatNormalized(t *testing.T) {
+	// 6/4 encoded as is, rather than normalized by big.Rat
+	b := append([]byte{BB_RAT}, EncodeInt64(6)...)
+	b = append(b, EncodeInt64(4)...)
+
+	r, _, err := DecodeRat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Num().Int64() != 3 || r.Denom().Int64() != 2 {
+		t.Error("expected 3/2, got", r)
+	}
+}
+
+func TestDecodeRatInvalid(t *testing.T) {
+	valid := EncodeRat(big.NewRat(-2, 7))
+
+	invalid := [][]byte{
+		{BB_RAT},
+		valid[:len(valid)-1],
+		append(append([]byte{BB_RAT}, EncodeInt64(1)...), EncodeInt64(0)...),
+		append(append([]byte{BB_RAT}, EncodeInt64(1)...), EncodeInt64(-3)...),
+		append(append([]byte{BB_RAT}, EncodeInt64(1)...), EncodeUint64(3)...),
+		append(append([]byte{BB_RAT}, EncodeBool(true)...), EncodeInt64(3)...),
+	}
+
+	for _, b := range invalid {
+		if _, _, err := DecodeRat(b); !errors.Is(err, CorruptedBufferError) {
+			t.Error(b, "expected CorruptedBufferError, got", err)
+		}
+
+		if _, err := ValueLen(b); err == nil {
+			t.Error(b, "expected value length error")
+		}
+	}
+
+	if _, _, err := DecodeRat(EncodeInt64(1)); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected CorruptedBufferError for int, got", err)
+	}
+}
+
+func TestDecoderRat(t *testing.T) {
+	r := big.NewRat(-22, 7)
+
+	b := EncodeRat(r)
+	b = appendDesc(b, EncodeRat(r))
+
+	d := NewDecoder(bytes.NewReader(b))
+
+	for i := 0; i < 2; i++ {
+		v, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if dr, ok := v.(*big.Rat); !ok || dr.Cmp(r) != 0 {
+			t.Error("expected", r, "got", v)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package typedbuffer
+
+//
+// Encode one or more values as a record, prefixed by the number of values,
+// so that records can be concatenated and decoded unambiguously.
+// Records can contain nil values (encoded as nil first), e.g. for heterogeneous arrays or sparse keys
+//
+func EncodeRecord(values ...interface{}) ([]byte, error) {
+	return EncodeRecordNils(true, values...)
+}
+
+//
+// Encode one or more values as a record (see EncodeRecord), with nil values encoded
+// as nil first or nil last according to nilFirst.
+// Slices count as one value per element, as they are decoded
+//
+func EncodeRecordNils(nilFirst bool, values ...interface{}) ([]byte, error) {
+	body, err := appendValues(nil, nilFirst, values)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := countValues(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(EncodeUint64(uint64(n)), body...), nil
+}
+
+//
+// Return the number of values in typed buffer b
+//
+func countValues(b []byte) (int, error) {
+	n := 0
+
+	for len(b) > 0 {
+		l, err := ValueLen(b)
+		if err != nil {
+			return 0, err
+		}
+
+		b = b[l:]
+		n++
+	}
+
+	return n, nil
+}
+
+//
+// Decode a record encoded with EncodeRecord or EncodeRecordNils. Returns decoded values and remaining buffer
+//
+func DecodeRecord(b []byte) ([]interface{}, []byte, error) {
+	v, next, err := Decode(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n, ok := v.(uint64)
+	if !ok || n > uint64(len(next)) { // each value takes at least 1 byte
+		return nil, nil, CorruptedBufferError
+	}
+
+	values, rest, err := DecodeN(next, int(n))
+	if err != nil {
+		return nil, nil, withOffset(err, len(b)-len(next))
+	}
+
+	return values, rest, nil
+}
+
+//
+// Encode one or more values as a record followed by a terminator, so that concatenated records
+// sort as the sequences of their values: byte slices and strings are encoded as ordered bytes
+// (see EncodeKey), and a record sorts before a longer record with the same first values
+// (unless the next value is nil first)
+//
+func EncodeSortableRecord(values ...interface{}) ([]byte, error) {
+	b, err := EncodeKey(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, BB_RECORD_END), nil
+}
+
+//
+// Decode a record encoded with EncodeSortableRecord. Returns decoded values and the buffer after the terminator
+//
+func DecodeSortableRecord(b []byte) ([]interface{}, []byte, error) {
+	values := []interface{}{}
+	off := 0
+
+	for {
+		if len(b) == 0 {
+			return nil, nil, CorruptedBufferError
+		}
+
+		if b[0] == BB_RECORD_END {
+			return values, b[1:], nil
+		}
+
+		v, next, err := Decode(b)
+		if err != nil {
+			return nil, nil, withOffset(err, off)
+		}
+
+		values = append(values, v)
+		off += len(b) - len(next)
+		b = next
+	}
+}
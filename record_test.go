@@ -0,0 +1,209 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func MustEncodeRecord(values ...interface{}) []byte {
+	b, err := EncodeRecord(values...)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestRecord(t *testing.T) {
+	records := [][]interface{}{
+		{int64(1), "hello", true},
+		{},
+		{nil, uint64(1000)},
+	}
+
+	var b []byte
+	for _, r := range records {
+		b = append(b, MustEncodeRecord(r...)...)
+	}
+
+	for _, r := range records {
+		values, next, err := DecodeRecord(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(values) != len(r) {
+			t.Fatal("expected", r, "got", values)
+		}
+
+		for i, v := range values {
+			if sb, ok := v.([]byte); ok {
+				v = string(sb)
+			}
+
+			if v != r[i] {
+				t.Error("expected", r[i], "got", v)
+			}
+		}
+
+		b = next
+	}
+
+	if len(b) != 0 {
+		t.Error("unexpected remaining buffer", b)
+	}
+
+	b = MustEncodeRecord(1, 2, 3)
+	if _, _, err := DecodeRecord(b[:len(b)-1]); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := DecodeRecord(MustEncode("x")); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestRecordSlice(t *testing.T) {
+	// slices are encoded as one value per element
+	b := append(MustEncodeRecord(1, []int64{2, 3, 4}, []string{"a"}), One...)
+
+	values, next, err := DecodeRecord(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []interface{}{int64(1), int64(2), int64(3), int64(4), "a"}
+
+	if len(values) != len(expected) {
+		t.Fatal("expected", expected, "got", values)
+	}
+
+	for i, v := range values {
+		if sb, ok := v.([]byte); ok {
+			v = string(sb)
+		}
+
+		if v != expected[i] {
+			t.Error("expected", expected[i], "got", v)
+		}
+	}
+
+	if len(next) != 1 || next[0] != One[0] {
+		t.Error("expected remaining", One, "got", next)
+	}
+}
+
+func TestRecordNils(t *testing.T) {
+	xs := []interface{}{1, nil, "x"}
+
+	for _, nilFirst := range []bool{true, false} {
+		b, err := EncodeRecordNils(nilFirst, xs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		values, next, err := DecodeRecord(append(b, One...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(values) != 3 || values[0] != int64(1) || values[1] != nil || string(values[2].([]byte)) != "x" {
+			t.Error("unexpected", values)
+		}
+
+		if len(next) != 1 || next[0] != One[0] {
+			t.Error("expected remaining", One, "got", next)
+		}
+	}
+
+	// nil elements sort according to nilFirst
+	first, _ := EncodeRecordNils(true, 1, nil)
+	last, _ := EncodeRecordNils(false, 1, nil)
+	value, _ := EncodeRecordNils(true, 1, 0)
+
+	if string(first) >= string(value) || string(value) >= string(last) {
+		t.Error("expected", first, "<", value, "<", last)
+	}
+}
+
+func TestSortableRecord(t *testing.T) {
+	// pairs of concatenated records, in increasing order
+	pairs := [][2][]interface{}{
+		{{1, "dog", nil}, {"a"}}, // nil first sorts before the terminator
+		{{1, "dog"}, {"z"}},
+		{{1, "dog"}, {"zz", 1}},
+		{{1, "dogs"}, {"a"}},
+		{{1, "dogs", 1}, {"a"}},
+		{{1, "e"}, {"a"}},
+		{{2}, {"a"}},
+		{{2}, {"b"}},
+		{{2, ""}, {"a"}},
+	}
+
+	var prev []byte
+
+	for _, p := range pairs {
+		r1, err := EncodeSortableRecord(p[0]...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r2, err := EncodeSortableRecord(p[1]...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b := append(r1, r2...)
+
+		if prev != nil && bytes.Compare(prev, b) != -1 {
+			t.Error(prev, "should be less than", b, p)
+		}
+
+		for _, r := range p {
+			values, next, err := DecodeSortableRecord(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(values) != len(r) {
+				t.Fatal("expected", r, "got", values)
+			}
+
+			for i, v := range values {
+				expected := r[i]
+				if i, ok := expected.(int); ok {
+					expected = int64(i)
+				}
+
+				if sb, ok := v.([]byte); ok {
+					v = string(sb)
+				}
+
+				if v != expected {
+					t.Error("expected", expected, "got", v)
+				}
+			}
+
+			b = next
+		}
+
+		if len(b) != 0 {
+			t.Error("unexpected remaining buffer", b)
+		}
+
+		prev = append(r1, r2...)
+	}
+
+	// with length prefixed strings, "dogs" sorts after "e"
+	if bytes.Compare(MustEncodeRecord(1, "dogs"), MustEncodeRecord(1, "e")) != 1 {
+		t.Error("expected records to sort by string length")
+	}
+
+	b, _ := EncodeSortableRecord(1, 2)
+	if _, _, err := DecodeSortableRecord(b[:len(b)-1]); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := DecodeSortableRecord(nil); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
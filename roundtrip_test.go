@@ -0,0 +1,232 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+//
+// Return the value Decode is expected to return for encoded value v
+//
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case int:
+		return int64(t)
+	case int8:
+		return int64(t)
+	case int16:
+		return int64(t)
+	case int32:
+		return int64(t)
+	case uint:
+		return uint64(t)
+	case uint8:
+		return uint64(t)
+	case uint16:
+		return uint64(t)
+	case uint32:
+		return uint64(t)
+	case float32:
+		return float64(t)
+	case string:
+		return []byte(t)
+	case time.Time:
+		return t.Truncate(time.Millisecond).UTC()
+	case *big.Int:
+		if t.IsInt64() {
+			return t.Int64()
+		}
+	}
+
+	return v
+}
+
+//
+// Return a set of boundary and random values of all supported types
+//
+func roundTripValues() []interface{} {
+	values := []interface{}{
+		nil, true, false,
+		0, 7, -8, 8, -9, 255, 256, -256, -257, math.MaxInt32, math.MinInt32,
+		int8(math.MinInt8), int8(math.MaxInt8), int16(math.MinInt16), int16(math.MaxInt16),
+		int32(math.MinInt32), int32(math.MaxInt32), int64(math.MinInt64), int64(math.MaxInt64),
+		uint(0), uint8(math.MaxUint8), uint16(math.MaxUint16), uint32(math.MaxUint32),
+		uint64(0), uint64(16), uint64(17), uint64(math.MaxUint64),
+		float32(1.5), 0.0, math.Copysign(0, -1), math.SmallestNonzeroFloat64, -math.MaxFloat64, math.Inf(1), math.Inf(-1),
+		"", "hello", string(make([]byte, 60)), string(make([]byte, 61)), string(make([]byte, 317)), string(make([]byte, 65851)),
+		[]byte{}, []byte{0, 1, 2},
+		time.Unix(0, 0), time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC),
+		new(big.Int).Lsh(big.NewInt(1), 64), new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 100)), big.NewInt(-5),
+	}
+
+	r := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 200; i++ {
+		shift := uint(r.Intn(64))
+
+		s := make([]byte, r.Intn(100))
+		r.Read(s)
+
+		values = append(values,
+			r.Int63()>>shift,
+			-r.Int63()>>shift,
+			r.Uint64()>>shift,
+			r.NormFloat64()*math.Pow(10, float64(r.Intn(40)-20)),
+			r.Intn(2) == 0,
+			string(s),
+			time.UnixMilli(r.Int63n(1<<42)-(1<<41)),
+		)
+	}
+
+	return values
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, v := range roundTripValues() {
+		b, err := Encode(v)
+		if err != nil {
+			t.Fatalf("%T %v: %v", v, v, err)
+		}
+
+		d, err := DecodeOne(b)
+		if err != nil {
+			t.Fatalf("%T %v: %v", v, v, err)
+		}
+
+		expected := normalize(v)
+
+		if eb, ok := expected.(*big.Int); ok {
+			if db, ok := d.(*big.Int); !ok || db.Cmp(eb) != 0 {
+				t.Errorf("expected %T %v, got %T %v", expected, expected, d, d)
+			}
+		} else if !reflect.DeepEqual(d, expected) {
+			t.Errorf("expected %T %v, got %T %v", expected, expected, d, d)
+		}
+
+		if l, err := EncodedLen(v); err != nil || l != len(b) {
+			t.Errorf("%T %v: expected length %v, got %v %v", v, v, len(b), l, err)
+		}
+
+		desc, err := EncodeDesc(v)
+		if err != nil {
+			t.Fatalf("%T %v: %v", v, v, err)
+		}
+
+		if dd, err := DecodeOne(desc); err != nil || !reflect.DeepEqual(normalize(dd), normalize(d)) {
+			t.Errorf("%T %v: expected desc %v, got %v %v", v, v, d, dd, err)
+		}
+	}
+}
+
+func TestRoundTripAll(t *testing.T) {
+	values := roundTripValues()
+
+	b, err := EncodePacked(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeAll(false, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(values) {
+		t.Fatal("expected", len(values), "values, got", len(decoded))
+	}
+
+	for i, v := range values {
+		if eb, ok := v.(*big.Int); ok && !eb.IsInt64() {
+			continue
+		}
+
+		if expected := normalize(v); !reflect.DeepEqual(decoded[i], expected) {
+			t.Errorf("%d: expected %T %v, got %T %v", i, expected, expected, decoded[i], decoded[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write(b)
+
+	dec := NewDecoder(&buf)
+	for i := range values {
+		if _, err := dec.Decode(); err != nil {
+			t.Fatal(i, err)
+		}
+	}
+}
+
+//
+// Check that each encoded value sorts strictly before the next one
+//
+func checkMonotonic(t *testing.T, name string, encoded [][]byte, values []interface{}) {
+	for i := 1; i < len(encoded); i++ {
+		if bytes.Compare(encoded[i-1], encoded[i]) != -1 {
+			t.Errorf("%s: %v (%v) should sort before %v (%v)", name, values[i-1], encoded[i-1], values[i], encoded[i])
+		}
+	}
+}
+
+func TestMonotonicOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+
+	ints := []int64{math.MinInt64, math.MinInt64 + 1, math.MinInt32 - 1, math.MinInt32, -65537, -65536, -257, -256, -9, -8, -1,
+		0, 1, 7, 8, 255, 256, 65535, 65536, math.MaxInt32, math.MaxInt32 + 1, math.MaxInt64 - 1, math.MaxInt64}
+	for i := 0; i < 1000; i++ {
+		ints = append(ints, int64(r.Uint64())>>uint(r.Intn(64)))
+	}
+	sort.Slice(ints, func(i, j int) bool { return ints[i] < ints[j] })
+
+	uints := []uint64{0, 1, 15, 16, 17, 255, 256, 65535, 65536, math.MaxUint32, math.MaxUint32 + 1, math.MaxUint64 - 1, math.MaxUint64}
+	for i := 0; i < 1000; i++ {
+		uints = append(uints, r.Uint64()>>uint(r.Intn(64)))
+	}
+	sort.Slice(uints, func(i, j int) bool { return uints[i] < uints[j] })
+
+	floats := []float64{math.Inf(-1), -math.MaxFloat64, -1, -math.SmallestNonzeroFloat64, math.Copysign(0, -1),
+		0, math.SmallestNonzeroFloat64, 1, math.MaxFloat64, math.Inf(1)}
+	for i := 0; i < 1000; i++ {
+		floats = append(floats, r.NormFloat64()*math.Pow(10, float64(r.Intn(600)-300)))
+	}
+	sort.Slice(floats, func(i, j int) bool {
+		if floats[i] == 0 && floats[j] == 0 {
+			return math.Signbit(floats[i]) && !math.Signbit(floats[j])
+		}
+		return floats[i] < floats[j]
+	})
+
+	var encoded [][]byte
+	var values []interface{}
+
+	add := func(v interface{}, b []byte) {
+		if len(encoded) > 0 && bytes.Equal(encoded[len(encoded)-1], b) {
+			return // duplicate random value
+		}
+
+		encoded = append(encoded, b)
+		values = append(values, v)
+	}
+
+	for _, i := range ints {
+		add(i, EncodeInt64(i))
+	}
+	checkMonotonic(t, "int64", encoded, values)
+
+	encoded, values = nil, nil
+	for _, u := range uints {
+		add(u, EncodeUint64(u))
+	}
+	checkMonotonic(t, "uint64", encoded, values)
+
+	encoded, values = nil, nil
+	for _, f := range floats {
+		add(f, EncodeFloat64(f))
+	}
+	checkMonotonic(t, "float64", encoded, values)
+}
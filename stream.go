@@ -0,0 +1,241 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+//
+// A Decoder reads and decodes values from an input stream.
+// If Schema is set, field i of a record is decoded according to Schema[i]: String fields are returned
+// as string and Bytes fields as []byte, and a field that doesn't match its kind (or nil) is an error.
+// Invalid matches any kind, and fields after the schema are decoded as Decode does
+//
+type Decoder struct {
+	Schema []Kind
+
+	r     io.Reader
+	field int // index of the next field in the current record
+}
+
+//
+// Create a new Decoder that reads from r
+//
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+//
+// Decode next value from the input stream.
+// Returns io.EOF when the stream is exhausted and io.ErrUnexpectedEOF
+// if the stream ends in the middle of a value
+//
+func (d *Decoder) Decode() (interface{}, error) {
+	var tag [1]byte
+
+	if _, err := io.ReadFull(d.r, tag[:]); err != nil {
+		return nil, err
+	}
+
+	b, err := d.readValue(tag[0], 0)
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := Decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	field := d.field
+	d.field++
+
+	if field < len(d.Schema) {
+		return decodeField(b, v, d.Schema[field])
+	}
+
+	return v, nil
+}
+
+//
+// Start a new record: the next value is decoded according to the first kind in Schema
+//
+func (d *Decoder) NextRecord() {
+	d.field = 0
+}
+
+//
+// Check that value v, decoded from b, matches kind k, and convert it to a string if k is String
+//
+func decodeField(b []byte, v interface{}, k Kind) (interface{}, error) {
+	kb, err := TypeOf(b)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case k == Invalid || kb == Nil:
+		return v, nil
+
+	case k == String && kb == Bytes:
+		return string(v.([]byte)), nil
+
+	case k != kb:
+		return nil, fmt.Errorf("%w: expected %v, got %v", CorruptedBufferError, k, kb)
+	}
+
+	return v, nil
+}
+
+//
+// Read the rest of the value starting with tag. Returns the encoded value, including the tag
+// (all bytes are xor'ed with inv, see valueLen)
+//
+func (d *Decoder) readValue(tag, inv byte) ([]byte, error) {
+	k := tag ^ inv
+
+	switch k {
+	case BB_DESC:
+		if inv != 0 {
+			return nil, CorruptedBufferError
+		}
+
+		var c [1]byte
+		if err := d.readFull(c[:]); err != nil {
+			return nil, err
+		}
+
+		b, err := d.readValue(c[0], 0xFF)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte{tag}, b...), nil
+
+	case BB_BYTES_ORDERED:
+		return d.readOrdered(tag, inv)
+
+	case BB_RAT:
+		return d.readRat(tag, inv)
+	}
+
+	h := headerLen(k)
+
+	b := make([]byte, 1+h)
+	b[0] = tag
+
+	if err := d.readFull(b[1:]); err != nil {
+		return nil, err
+	}
+
+	var hb [4]byte
+	for i := 0; i < h; i++ {
+		hb[i] = b[1+i] ^ inv
+	}
+
+	n, err := payloadLen(k, hb[:h])
+	if err != nil {
+		return nil, err
+	}
+
+	// don't trust the length header to preallocate the payload
+	buf := bytes.NewBuffer(b)
+
+	if _, err := io.CopyN(buf, d.r, int64(n)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+//
+// Read the numerator and denominator of a rational number
+//
+func (d *Decoder) readRat(tag, inv byte) ([]byte, error) {
+	b := []byte{tag}
+
+	for i := 0; i < 2; i++ {
+		c, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		// check the kind first, to avoid recursing on nested values
+		if kindOf(c^inv) != Int {
+			return nil, CorruptedBufferError
+		}
+
+		part, err := d.readValue(c, inv)
+		if err != nil {
+			return nil, err
+		}
+
+		b = append(b, part...)
+	}
+
+	return b, nil
+}
+
+//
+// Read ordered bytes up to and including the terminator
+//
+func (d *Decoder) readOrdered(tag, inv byte) ([]byte, error) {
+	b := []byte{tag}
+
+	var c [1]byte
+
+	for {
+		if err := d.readFull(c[:]); err != nil {
+			return nil, err
+		}
+
+		b = append(b, c[0])
+		if c[0]^inv != BB_ORDERED_ESC {
+			continue
+		}
+
+		if err := d.readFull(c[:]); err != nil {
+			return nil, err
+		}
+
+		b = append(b, c[0])
+		if c[0]^inv == BB_ORDERED_END {
+			return b, nil
+		}
+	}
+}
+
+//
+// Read exactly len(b) bytes, reporting a missing payload as io.ErrUnexpectedEOF
+//
+func (d *Decoder) readFull(b []byte) error {
+	_, err := io.ReadFull(d.r, b)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+//
+// Read one byte, reporting a missing byte as io.ErrUnexpectedEOF
+//
+func (d *Decoder) readByte() (byte, error) {
+	if br, ok := d.r.(io.ByteReader); ok {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return c, err
+	}
+
+	var c [1]byte
+	err := d.readFull(c[:])
+	return c[0], err
+}
@@ -0,0 +1,154 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	b := MustEncode(10, "hello", -100000, false, nil, uint64(1000))
+
+	dec := NewDecoder(bytes.NewReader(b))
+	expected := MustDecodeAll(b)
+
+	for i := 0; ; i++ {
+		v, err := dec.Decode()
+		if err == io.EOF {
+			if i != len(expected) {
+				t.Error("expected", len(expected), "values, got", i)
+			}
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sb, ok := v.([]byte); ok {
+			v = string(sb)
+		}
+
+		t.Log(v)
+		if v != expected[i] {
+			t.Error("expected", expected[i], "got", v)
+		}
+	}
+}
+
+func TestDecoderTruncated(t *testing.T) {
+	b := MustEncode(10, "hello", -100000)
+
+	dec := NewDecoder(bytes.NewReader(b[:len(b)-1]))
+
+	if _, err := dec.Decode(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dec.Decode(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dec.Decode(); err != io.ErrUnexpectedEOF {
+		t.Error("expected", io.ErrUnexpectedEOF, "got", err)
+	}
+}
+
+func TestDecoderBytesOrdered(t *testing.T) {
+	b := append(EncodeBytesOrdered([]byte("a\x00b")), EncodeInt(42)...)
+
+	dec := NewDecoder(bytes.NewReader(b))
+
+	if v, err := dec.Decode(); err != nil || string(v.([]byte)) != "a\x00b" {
+		t.Errorf("expected %q, got %q %v", "a\x00b", v, err)
+	}
+
+	if v, err := dec.Decode(); err != nil || v != int64(42) {
+		t.Error("expected 42, got", v, err)
+	}
+
+	dec = NewDecoder(bytes.NewReader(b[:3]))
+	if _, err := dec.Decode(); err != io.ErrUnexpectedEOF {
+		t.Error("expected", io.ErrUnexpectedEOF, "got", err)
+	}
+}
+
+func TestDecoderDesc(t *testing.T) {
+	b, _ := EncodeDesc(-100000, "hello")
+	b = append(b, EncodeInt(42)...)
+
+	dec := NewDecoder(bytes.NewReader(b))
+
+	for _, expected := range []interface{}{int64(-100000), "hello", int64(42)} {
+		v, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sb, ok := v.([]byte); ok {
+			v = string(sb)
+		}
+
+		if v != expected {
+			t.Error("expected", expected, "got", v)
+		}
+	}
+
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Error("expected", io.EOF, "got", err)
+	}
+}
+
+func TestDecoderSchema(t *testing.T) {
+	blob := []byte{0x00, 0xFF}
+
+	var b []byte
+	b = append(b, MustEncode("first", blob, 1)...)
+	b = append(b, MustEncode("second", nil, 2)...)
+	b = append(b, MustEncode("third", 3, 3)...)
+
+	dec := NewDecoder(bytes.NewReader(b))
+	dec.Schema = []Kind{String, Bytes}
+
+	for _, name := range []string{"first", "second"} {
+		v, err := dec.Decode()
+		if err != nil || v != name {
+			t.Errorf("expected string %v, got %T(%v) %v", name, v, v, err)
+		}
+
+		v, err = dec.Decode()
+		if vb, ok := v.([]byte); err != nil || (v != nil && (!ok || !bytes.Equal(vb, blob))) {
+			t.Errorf("expected bytes or nil, got %T(%v) %v", v, v, err)
+		}
+
+		// fields after the schema use the default decoding
+		if v, err := dec.Decode(); err != nil {
+			t.Error(err)
+		} else if _, ok := v.(int64); !ok {
+			t.Errorf("expected int64, got %T(%v)", v, v)
+		}
+
+		dec.NextRecord()
+	}
+
+	if v, err := dec.Decode(); err != nil || v != "third" {
+		t.Errorf("expected string third, got %T(%v) %v", v, v, err)
+	}
+
+	if _, err := dec.Decode(); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	// Invalid matches any kind
+	dec = NewDecoder(bytes.NewReader(MustEncode(1, "x")))
+	dec.Schema = []Kind{Invalid, String}
+
+	if v, err := dec.Decode(); err != nil || v != int64(1) {
+		t.Error("expected 1, got", v, err)
+	}
+
+	if v, err := dec.Decode(); err != nil || v != "x" {
+		t.Error("expected x, got", v, err)
+	}
+}
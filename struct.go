@@ -0,0 +1,452 @@
+package typedbuffer
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	bigIntType   = reflect.TypeOf((*big.Int)(nil))
+	ratType      = reflect.TypeOf((*big.Rat)(nil))
+	ipType       = reflect.TypeOf(net.IP(nil))
+	durationType = reflect.TypeOf(time.Duration(0))
+	uuidType     = reflect.TypeOf([16]byte{})
+	enumType     = reflect.TypeOf(EnumValue{})
+	nilValueType = reflect.TypeOf(NilValue{})
+)
+
+//
+// A struct field to be encoded, with its index in the struct and the options
+// from its `typedbuffer` tag
+//
+type structField struct {
+	index    int
+	name     string
+	desc     bool
+	nilFirst bool
+}
+
+//
+// Return the fields of struct type t that are encoded, in declaration order.
+// Only exported fields are encoded, and fields can be configured with a comma separated list
+// of options in a `typedbuffer` tag:
+//
+//	typedbuffer:"-"            skip the field
+//	typedbuffer:"order=desc"   encode the field in descending order (order=asc is the default)
+//	typedbuffer:"nil=last"     encode nil pointers as nil last (nil=first is the default)
+//
+func structFields(t reflect.Type) ([]structField, error) {
+	fields := make([]structField, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("typedbuffer")
+		if tag == "-" {
+			continue
+		}
+
+		sf := structField{index: i, name: f.Name, nilFirst: true}
+
+		for _, opt := range strings.Split(tag, ",") {
+			switch strings.TrimSpace(opt) {
+			case "":
+			case "order=asc":
+				sf.desc = false
+			case "order=desc":
+				sf.desc = true
+			case "nil=first":
+				sf.nilFirst = true
+			case "nil=last":
+				sf.nilFirst = false
+			default:
+				return nil, fmt.Errorf("%w: field %s: invalid tag option %q", NoEncoding, f.Name, opt)
+			}
+		}
+
+		fields = append(fields, sf)
+	}
+
+	return fields, nil
+}
+
+//
+// Encode the exported fields of struct v (or pointer to struct) in declaration order,
+// according to their `typedbuffer` tags (see structFields).
+// Returns an error wrapping NoEncoding, with the field name, if a field type is not supported
+// or is a slice encoded as several values (e.g. []int64), that couldn't be decoded as one field
+//
+func EncodeStruct(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T is not a struct", NoEncoding, v)
+	}
+
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	b := []byte{}
+
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+
+		enc, err := appendReflect(nil, f.nilFirst, fv)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.name, err)
+		}
+
+		if n, err := countValues(enc); err != nil || n != 1 {
+			return nil, fmt.Errorf("field %s: %w: %s is not a single value", f.name, NoEncoding, fv.Type())
+		}
+
+		if f.desc {
+			b = appendDesc(b, enc)
+		} else {
+			b = append(b, enc...)
+		}
+	}
+
+	return b, nil
+}
+
+//
+// Decode a typed buffer encoded with EncodeStruct into the struct pointed to by ptr
+// (skipped fields are left unchanged).
+// Returns TrailingBytesError if there are bytes left after the last field
+//
+func DecodeStruct(b []byte, ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T is not a pointer to struct", NoEncoding, ptr)
+	}
+
+	rv = rv.Elem()
+
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		v, next, err := Decode(b)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.name, err)
+		}
+
+		if err := setReflect(rv.Field(f.index), v); err != nil {
+			return fmt.Errorf("field %s: %w", f.name, err)
+		}
+
+		b = next
+	}
+
+	if len(b) > 0 {
+		return TrailingBytesError
+	}
+
+	return nil
+}
+
+//
+// Encode value v according to its kind, as Encode but without boxing v into an interface{} first
+// (only time.Time, *big.Int and *big.Rat values are read with Interface, and can't be read from unexported fields).
+// Pointers and interfaces are followed, and nil pointers, nil interfaces and the zero Value
+// are encoded as nil
+//
+func EncodeValue(v reflect.Value) ([]byte, error) {
+	return appendReflect(nil, true, v)
+}
+
+//
+// Append encoded value v to b, according to its kind
+//
+func appendReflect(b []byte, nilFirst bool, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return AppendNil(b, nilFirst), nil
+	}
+
+	switch v.Type() {
+	case durationType:
+		return appendDuration(b, time.Duration(v.Int())), nil
+
+	case uuidType:
+		var u [16]byte
+		for i := range u {
+			u[i] = byte(v.Index(i).Uint())
+		}
+		return AppendUUID(b, u), nil
+
+	case enumType:
+		e := EnumValue{Type: uint16(v.FieldByName("Type").Uint()), Value: v.FieldByName("Value").Int()}
+		return appendEnum(b, e), nil
+
+	case nilValueType:
+		return AppendNil(b, v.FieldByName("First").Bool()), nil
+
+	case ipType:
+		if v.IsNil() {
+			return AppendNil(b, nilFirst), nil
+		}
+
+		ip := net.IP(v.Bytes())
+		if b = appendIP(b, ip); b == nil {
+			return nil, fmt.Errorf("%w: invalid IP address %v", NoEncoding, ip)
+		}
+		return b, nil
+
+	case timeType, bigIntType, ratType:
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return AppendNil(b, nilFirst), nil
+		}
+
+		if !v.CanInterface() {
+			return nil, fmt.Errorf("%w: %s value from an unexported field", NoEncoding, v.Type())
+		}
+
+		return appendValues(b, nilFirst, []interface{}{v.Interface()})
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return AppendBool(b, v.Bool()), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return AppendInt64(b, v.Int()), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return AppendUint64(b, v.Uint()), nil
+
+	case reflect.Float32, reflect.Float64:
+		return appendFloat64(b, v.Float()), nil
+
+	case reflect.String:
+		if err := checkBytesLen(v.Len()); err != nil {
+			return nil, err
+		}
+		return appendString(b, v.String()), nil
+
+	case reflect.Slice:
+		return appendReflectSlice(b, v)
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return AppendNil(b, nilFirst), nil
+		}
+		return appendReflect(b, nilFirst, v.Elem())
+	}
+
+	return nil, fmt.Errorf("%w: unsupported type %s", NoEncoding, v.Type())
+}
+
+//
+// Append encoded slice v to b: slices of bytes and bools are encoded as one value,
+// slices of ints, uint64 and strings as one value per element (as Encode does)
+//
+func appendReflectSlice(b []byte, v reflect.Value) ([]byte, error) {
+	n := v.Len()
+
+	switch v.Type().Elem().Kind() {
+	case reflect.Uint8:
+		if err := checkBytesLen(n); err != nil {
+			return nil, err
+		}
+		return AppendBytes(b, v.Bytes()), nil
+
+	case reflect.Bool:
+		if err := checkBoolsLen(n); err != nil {
+			return nil, err
+		}
+
+		xs := make([]bool, n)
+		for i := range xs {
+			xs[i] = v.Index(i).Bool()
+		}
+		return appendBools(b, xs), nil
+
+	case reflect.Int, reflect.Int64:
+		for i := 0; i < n; i++ {
+			b = AppendInt64(b, v.Index(i).Int())
+		}
+		return b, nil
+
+	case reflect.Uint64:
+		for i := 0; i < n; i++ {
+			b = AppendUint64(b, v.Index(i).Uint())
+		}
+		return b, nil
+
+	case reflect.String:
+		for i := 0; i < n; i++ {
+			s := v.Index(i).String()
+			if err := checkBytesLen(len(s)); err != nil {
+				return nil, err
+			}
+			b = appendString(b, s)
+		}
+		return b, nil
+	}
+
+	return nil, fmt.Errorf("%w: unsupported type %s", NoEncoding, v.Type())
+}
+
+//
+// Set v to decoded value x. Returns CorruptedBufferError if x doesn't match the kind of v
+// and ValueOutOfRangeError if x doesn't fit in v
+//
+func setReflect(v reflect.Value, x interface{}) error {
+	if v.Kind() == reflect.Ptr && v.Type() != bigIntType && v.Type() != ratType {
+		if x == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+
+		p := reflect.New(v.Type().Elem())
+		if err := setReflect(p.Elem(), x); err != nil {
+			return err
+		}
+
+		v.Set(p)
+		return nil
+	}
+
+	switch v.Type() {
+	case timeType:
+		t, ok := x.(time.Time)
+		if !ok {
+			return CorruptedBufferError
+		}
+
+		v.Set(reflect.ValueOf(t))
+		return nil
+
+	case bigIntType:
+		switch t := x.(type) {
+		case nil:
+			v.Set(reflect.Zero(bigIntType))
+		case int64:
+			v.Set(reflect.ValueOf(big.NewInt(t)))
+		case *big.Int:
+			v.Set(reflect.ValueOf(t))
+		default:
+			return CorruptedBufferError
+		}
+
+		return nil
+
+	case ratType:
+		switch t := x.(type) {
+		case nil:
+			v.Set(reflect.Zero(ratType))
+		case *big.Rat:
+			v.Set(reflect.ValueOf(t))
+		default:
+			return CorruptedBufferError
+		}
+
+		return nil
+
+	case durationType, uuidType, enumType:
+		if x == nil || reflect.TypeOf(x) != v.Type() {
+			return CorruptedBufferError
+		}
+
+		v.Set(reflect.ValueOf(x))
+		return nil
+
+	case ipType:
+		switch t := x.(type) {
+		case nil:
+			v.Set(reflect.Zero(ipType))
+		case net.IP:
+			v.Set(reflect.ValueOf(append(net.IP{}, t...)))
+		default:
+			return CorruptedBufferError
+		}
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if t, ok := x.(bool); ok {
+			v.SetBool(t)
+			return nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t, ok := x.(int64); ok {
+			if v.OverflowInt(t) {
+				return ValueOutOfRangeError
+			}
+
+			v.SetInt(t)
+			return nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if t, ok := x.(uint64); ok {
+			if v.OverflowUint(t) {
+				return ValueOutOfRangeError
+			}
+
+			v.SetUint(t)
+			return nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if t, ok := x.(float64); ok {
+			v.SetFloat(t)
+			return nil
+		}
+
+	case reflect.String:
+		if t, ok := x.([]byte); ok {
+			v.SetString(string(t))
+			return nil
+		}
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Bool {
+			if t, ok := x.([]bool); ok {
+				xs := reflect.MakeSlice(v.Type(), len(t), len(t))
+				for i, c := range t {
+					xs.Index(i).SetBool(c)
+				}
+				v.Set(xs)
+				return nil
+			}
+
+			break
+		}
+
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return NoEncoding
+		}
+
+		if t, ok := x.([]byte); ok {
+			cb := make([]byte, len(t))
+			copy(cb, t)
+			v.SetBytes(cb)
+			return nil
+		}
+
+	default:
+		return NoEncoding
+	}
+
+	return CorruptedBufferError
+}
@@ -0,0 +1,312 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testStruct struct {
+	ID      int
+	Name    string
+	Active  bool
+	Score   float64
+	private int
+}
+
+func TestEncodeStruct(t *testing.T) {
+	s := testStruct{ID: 42, Name: "gopher", Active: true, Score: 1.5, private: 7}
+
+	b, err := EncodeStruct(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := MustEncode(42, "gopher", true, 1.5); !bytes.Equal(b, expected) {
+		t.Error("expected", expected, "got", b)
+	}
+
+	if pb, err := EncodeStruct(&s); err != nil || !bytes.Equal(pb, b) {
+		t.Error("expected", b, "got", pb, err)
+	}
+
+	var d testStruct
+	if err := DecodeStruct(b, &d); err != nil {
+		t.Fatal(err)
+	}
+
+	s.private = 0
+	if d != s {
+		t.Error("expected", s, "got", d)
+	}
+}
+
+func TestEncodeStructOrder(t *testing.T) {
+	a, _ := EncodeStruct(testStruct{ID: 1, Name: "b"})
+	b, _ := EncodeStruct(testStruct{ID: 1, Name: "ba"})
+	c, _ := EncodeStruct(testStruct{ID: 2, Name: "a"})
+
+	if bytes.Compare(a, b) != -1 || bytes.Compare(b, c) != -1 {
+		t.Error("expected", a, "<", b, "<", c)
+	}
+}
+
+func TestEncodeStructPointers(t *testing.T) {
+	type withPointers struct {
+		N *int
+		B []byte
+	}
+
+	n := 10
+	b, err := EncodeStruct(withPointers{N: &n, B: []byte("x")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var d withPointers
+	if err := DecodeStruct(b, &d); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.N == nil || *d.N != 10 || string(d.B) != "x" {
+		t.Error("unexpected", d)
+	}
+
+	b, _ = EncodeStruct(withPointers{})
+	if err := DecodeStruct(b, &d); err != nil || d.N != nil {
+		t.Error("expected nil pointer, got", d.N, err)
+	}
+}
+
+func TestEncodeStructErrors(t *testing.T) {
+	type unsupported struct {
+		ID   int
+		Tags map[string]string
+	}
+
+	_, err := EncodeStruct(unsupported{ID: 1})
+	if !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	} else {
+		t.Log(err)
+	}
+
+	if _, err := EncodeStruct(1); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	var s testStruct
+	if err := DecodeStruct(MustEncode(1, "a", true, 1.5), s); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if err := DecodeStruct(MustEncode("a", "a", true, 1.5), &s); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if err := DecodeStruct(MustEncode(1, "a", true, 1.5, 1), &s); err != TrailingBytesError {
+		t.Error("expected", TrailingBytesError, "got", err)
+	}
+
+	var small struct{ N int8 }
+	if err := DecodeStruct(MustEncode(1000), &small); !errors.Is(err, ValueOutOfRangeError) {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+}
+
+func TestEncodeStructTags(t *testing.T) {
+	type tagged struct {
+		Group   string
+		Created int64  `typedbuffer:"order=desc"`
+		Parent  *int   `typedbuffer:"nil=last"`
+		Cache   string `typedbuffer:"-"`
+	}
+
+	b, err := EncodeStruct(tagged{Group: "a", Created: 10, Cache: "ignored"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := append(MustEncode("a"), MustEncodeDesc(10)...)
+	expected = append(expected, NilLast...)
+
+	if !bytes.Equal(b, expected) {
+		t.Error("expected", expected, "got", b)
+	}
+
+	d := tagged{Cache: "kept"}
+	if err := DecodeStruct(b, &d); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Group != "a" || d.Created != 10 || d.Parent != nil || d.Cache != "kept" {
+		t.Error("unexpected", d)
+	}
+
+	// newer entries sort first within a group
+	older, _ := EncodeStruct(tagged{Group: "a", Created: 10})
+	newer, _ := EncodeStruct(tagged{Group: "a", Created: 20})
+	other, _ := EncodeStruct(tagged{Group: "b", Created: 30})
+
+	if bytes.Compare(newer, older) != -1 || bytes.Compare(older, other) != -1 {
+		t.Error("expected", newer, "<", older, "<", other)
+	}
+
+	// nil parents sort last
+	p := 1
+	withParent, _ := EncodeStruct(tagged{Group: "a", Created: 10, Parent: &p})
+	if bytes.Compare(withParent, older) != -1 {
+		t.Error(withParent, "should be less than", older)
+	}
+}
+
+func TestEncodeStructInvalidTag(t *testing.T) {
+	type invalid struct {
+		ID int `typedbuffer:"order=random"`
+	}
+
+	if _, err := EncodeStruct(invalid{}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	var d invalid
+	if err := DecodeStruct(MustEncode(1), &d); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+}
+
+func TestEncodeValue(t *testing.T) {
+	n := 42
+	var np *int
+	var iface interface{} = "hello"
+	ip := net.IPv4(192, 168, 0, 1)
+	big1 := new(big.Int).Lsh(big.NewInt(1), 100)
+	now := time.Now().Truncate(time.Millisecond)
+
+	tests := []struct {
+		v        reflect.Value
+		expected interface{}
+	}{
+		{reflect.ValueOf(true), true},
+		{reflect.ValueOf(int8(-3)), -3},
+		{reflect.ValueOf(int64(-100000)), -100000},
+		{reflect.ValueOf(uint16(300)), uint64(300)},
+		{reflect.ValueOf(uint64(1 << 63)), uint64(1 << 63)},
+		{reflect.ValueOf(float32(1.5)), 1.5},
+		{reflect.ValueOf(-2.25), -2.25},
+		{reflect.ValueOf("hello"), "hello"},
+		{reflect.ValueOf([]byte{1, 2, 3}), []byte{1, 2, 3}},
+		{reflect.ValueOf(&n), 42},
+		{reflect.ValueOf(np), nil},
+		{reflect.ValueOf(&iface).Elem(), "hello"},
+		{reflect.ValueOf(nil), nil},
+		{reflect.ValueOf(now), now},
+		{reflect.ValueOf(time.Minute), time.Minute},
+		{reflect.ValueOf(big1), big1},
+		{reflect.ValueOf(ip), ip},
+		{reflect.ValueOf([16]byte{1: 1}), [16]byte{1: 1}},
+		{reflect.ValueOf(EnumValue{Type: 1, Value: 2}), EnumValue{Type: 1, Value: 2}},
+		{reflect.ValueOf([]int64{-1, 2}), []int64{-1, 2}},
+		{reflect.ValueOf([]int{300}), []int{300}},
+		{reflect.ValueOf([]uint64{1, 1 << 40}), []uint64{1, 1 << 40}},
+		{reflect.ValueOf([]string{"a", "bc"}), []string{"a", "bc"}},
+		{reflect.ValueOf([]bool{true, false}), []bool{true, false}},
+		{reflect.ValueOf(big.NewRat(-1, 3)), big.NewRat(-1, 3)},
+		{reflect.ValueOf((*big.Rat)(nil)), nil},
+		{reflect.ValueOf(NilValue{First: false}), NilValue{First: false}},
+	}
+
+	for _, tt := range tests {
+		b, err := EncodeValue(tt.v)
+		if err != nil {
+			t.Error(tt.v, err)
+			continue
+		}
+
+		if expected := MustEncode(tt.expected); !bytes.Equal(b, expected) {
+			t.Error(tt.v, "expected", expected, "got", b)
+		}
+	}
+
+	if _, err := EncodeValue(reflect.ValueOf(map[string]int{})); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	// values read from unexported fields are encoded without Interface
+	type private struct {
+		n  int
+		s  string
+		id [16]byte
+		e  EnumValue
+		d  time.Duration
+		t  time.Time
+	}
+
+	p := reflect.ValueOf(private{n: 5, s: "x", id: [16]byte{1}, e: EnumValue{1, 2}, d: time.Second})
+
+	for i, expected := range []interface{}{5, "x", [16]byte{1}, EnumValue{1, 2}, time.Second} {
+		b, err := EncodeValue(p.Field(i))
+		if err != nil || !bytes.Equal(b, MustEncode(expected)) {
+			t.Error(p.Type().Field(i).Name, "expected", MustEncode(expected), "got", b, err)
+		}
+	}
+
+	if _, err := EncodeValue(p.Field(5)); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+}
+
+func TestEncodeStructBoolsAndRat(t *testing.T) {
+	type rec struct {
+		Flags []bool
+		Ratio *big.Rat
+	}
+
+	s := rec{Flags: []bool{true, false, true}, Ratio: big.NewRat(2, 3)}
+
+	b, err := EncodeStruct(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var d rec
+	if err := DecodeStruct(b, &d); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.Flags) != 3 || !d.Flags[0] || d.Flags[1] || !d.Flags[2] || d.Ratio.Cmp(s.Ratio) != 0 {
+		t.Error("expected", s, "got", d)
+	}
+}
+
+func TestEncodeStructNamedTypes(t *testing.T) {
+	type named struct {
+		Timeout time.Duration
+		ID      [16]byte
+		Color   EnumValue
+	}
+
+	s := named{Timeout: -time.Second, ID: [16]byte{15: 1}, Color: EnumValue{Type: 2, Value: 3}}
+
+	b, err := EncodeStruct(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := MustEncode(s.Timeout, s.ID, s.Color); !bytes.Equal(b, expected) {
+		t.Error("expected", expected, "got", b)
+	}
+
+	var d named
+	if err := DecodeStruct(b, &d); err != nil || d != s {
+		t.Error("expected", s, "got", d, err)
+	}
+
+	if err := DecodeStruct(MustEncode(1, s.ID, s.Color), &d); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
@@ -0,0 +1,98 @@
+package typedbuffer
+
+import (
+	"time"
+)
+
+// supported time resolutions, in the order of their resolution byte
+var timeUnits = []time.Duration{time.Second, time.Millisecond, time.Microsecond, time.Nanosecond}
+
+//
+// Encode Time truncated to unit (time.Second, time.Millisecond, time.Microsecond or time.Nanosecond).
+// Other units are replaced by the coarsest supported unit that is not longer than unit
+// (e.g. time.Minute by time.Second, 10*time.Millisecond by time.Millisecond, and units shorter than
+// a nanosecond by time.Nanosecond). The resolution is stored with the value, and values with different
+// resolutions sort according to the time they represent
+//
+func EncodeTimeWithUnit(t time.Time, unit time.Duration) []byte {
+	u := len(timeUnits) - 1
+	for i, tu := range timeUnits {
+		if tu <= unit {
+			u = i
+			break
+		}
+	}
+
+	unit = timeUnits[u]
+
+	ns := uint64(t.Nanosecond()) / uint64(unit) * uint64(unit)
+
+	b := make([]byte, 0, 14)
+	b = append(b, BB_DATE_UNIT)
+	b = appendFixed64(b, uint64(t.Unix())^DATE_SIGN_BIT)
+	b = append(b, byte(ns>>24), byte(ns>>16), byte(ns>>8), byte(ns))
+	return append(b, byte(u))
+}
+
+//
+// Decode first value in typed buffer as a Time encoded with EncodeTimeWithUnit.
+// Returns decoded time, its resolution and the remaining buffer
+//
+func DecodeTimeWithUnit(b []byte) (time.Time, time.Duration, []byte, error) {
+	if len(b) == 0 {
+		return time.Time{}, 0, nil, EmptyBufferError
+	}
+
+	if b[0] != BB_DATE_UNIT {
+		return time.Time{}, 0, nil, CorruptedBufferError
+	}
+
+	return decodeTimeUnit(b[1:])
+}
+
+//
+// Decode the payload of a BB_DATE_UNIT value
+//
+func decodeTimeUnit(b []byte) (time.Time, time.Duration, []byte, error) {
+	if len(b) < 13 {
+		return time.Time{}, 0, nil, CorruptedBufferError
+	}
+
+	sec := int64(uncompactUint64(b[0:8]) ^ DATE_SIGN_BIT)
+	ns := uncompactUint64(b[8:12])
+	u := b[12]
+
+	if !validTimeUnit(ns, u) {
+		return time.Time{}, 0, nil, CorruptedBufferError
+	}
+
+	return time.Unix(sec, int64(ns)).UTC(), timeUnits[u], b[13:], nil
+}
+
+//
+// Return the length of a BB_DATE_UNIT value (including the tag), checking that
+// nanoseconds and resolution are valid (all bytes are xor'ed with inv, see valueLen)
+//
+func timeUnitLen(b []byte, inv byte) (int, error) {
+	if len(b) < 14 {
+		return 0, CorruptedBufferError
+	}
+
+	var ns uint64
+	for _, c := range b[9:13] {
+		ns = ns<<8 | uint64(c^inv)
+	}
+
+	if !validTimeUnit(ns, b[13]^inv) {
+		return 0, CorruptedBufferError
+	}
+
+	return 14, nil
+}
+
+//
+// Return true if u is a valid resolution and ns a valid number of nanoseconds at that resolution
+//
+func validTimeUnit(ns uint64, u byte) bool {
+	return int(u) < len(timeUnits) && ns < uint64(time.Second) && ns%uint64(timeUnits[u]) == 0
+}
@@ -0,0 +1,111 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEncodeTimeWithUnit(t *testing.T) {
+	now := time.Date(2020, time.March, 4, 5, 6, 7, 123456789, time.UTC)
+
+	tests := []struct {
+		unit     time.Duration
+		expected time.Time
+	}{
+		{time.Second, time.Date(2020, time.March, 4, 5, 6, 7, 0, time.UTC)},
+		{time.Millisecond, time.Date(2020, time.March, 4, 5, 6, 7, 123000000, time.UTC)},
+		{time.Microsecond, time.Date(2020, time.March, 4, 5, 6, 7, 123456000, time.UTC)},
+		{time.Nanosecond, now},
+	}
+
+	for _, tt := range tests {
+		b := EncodeTimeWithUnit(now, tt.unit)
+
+		d, unit, next, err := DecodeTimeWithUnit(b)
+		if err != nil || !d.Equal(tt.expected) || unit != tt.unit || len(next) != 0 {
+			t.Error(tt.unit, "expected", tt.expected, "got", d, unit, next, err)
+		}
+
+		if v, err := DecodeOne(b); err != nil || !v.(time.Time).Equal(tt.expected) {
+			t.Error(tt.unit, "expected", tt.expected, "got", v, err)
+		}
+
+		if k, _ := TypeOf(b); k != Date {
+			t.Error("expected", Date, "got", k)
+		}
+
+		if l, err := ValueLen(append(b, One...)); err != nil || l != len(b) {
+			t.Error("expected length", len(b), "got", l, err)
+		}
+
+		desc := appendDesc(nil, b)
+		if v, err := DecodeOne(desc); err != nil || !v.(time.Time).Equal(tt.expected) {
+			t.Error(tt.unit, "expected", tt.expected, "got", v, err)
+		}
+	}
+}
+
+func TestEncodeTimeWithUnitOrder(t *testing.T) {
+	base := time.Date(1960, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []CompareItem{
+		{EncodeTimeWithUnit(base, time.Second), EncodeTimeWithUnit(base.Add(time.Millisecond), time.Millisecond)},
+		{EncodeTimeWithUnit(base.Add(time.Millisecond), time.Millisecond), EncodeTimeWithUnit(base.Add(2*time.Microsecond+time.Millisecond), time.Microsecond)},
+		{EncodeTimeWithUnit(base.Add(500*time.Microsecond), time.Microsecond), EncodeTimeWithUnit(base.Add(time.Millisecond), time.Millisecond)},
+		{EncodeTimeWithUnit(base.Add(999*time.Millisecond), time.Millisecond), EncodeTimeWithUnit(base.Add(time.Second), time.Second)},
+		{EncodeTimeWithUnit(base, time.Nanosecond), EncodeTimeWithUnit(time.Unix(0, 0), time.Second)},
+	}
+
+	for _, tt := range tests {
+		if bytes.Compare(tt.min, tt.max) != -1 || Compare(tt.min, tt.max) != -1 {
+			t.Error(tt.min, "should be less than", tt.max)
+		}
+	}
+}
+
+func TestDecodeTimeWithUnitErrors(t *testing.T) {
+	b := EncodeTimeWithUnit(time.Unix(0, 0), time.Second)
+
+	if _, _, _, err := DecodeTimeWithUnit(b[:len(b)-1]); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	b[len(b)-1] = 4
+	if _, _, _, err := DecodeTimeWithUnit(b); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, _, err := DecodeTimeWithUnit(EncodeTime(time.Now())); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestEncodeTimeWithUnsupportedUnit(t *testing.T) {
+	now := time.Date(2020, time.March, 4, 5, 6, 7, 123456789, time.UTC)
+
+	tests := []struct {
+		unit     time.Duration
+		expected time.Duration
+	}{
+		{time.Hour, time.Second},
+		{time.Minute, time.Second},
+		{10 * time.Millisecond, time.Millisecond},
+		{999 * time.Microsecond, time.Microsecond},
+		{2 * time.Nanosecond, time.Nanosecond},
+		{0, time.Nanosecond},
+		{-time.Second, time.Nanosecond},
+	}
+
+	for _, tt := range tests {
+		b := EncodeTimeWithUnit(now, tt.unit)
+
+		if !bytes.Equal(b, EncodeTimeWithUnit(now, tt.expected)) {
+			t.Error(tt.unit, "expected the encoding of", tt.expected, "got", b)
+		}
+
+		if _, unit, _, err := DecodeTimeWithUnit(b); err != nil || unit != tt.expected {
+			t.Error(tt.unit, "expected unit", tt.expected, "got", unit, err)
+		}
+	}
+}
@@ -24,6 +24,7 @@
  * Boolean:
  *   byte 0E - bool false
  *   byte 0F - bool true
+ *   byte 0D XXXXXXXX [n bytes] - array of XXXXXXXX bools, packed in n bytes (most significant bit first)
  *
  * Bytes:
  *   byte 10+size     : 0 to 15 bytes
@@ -31,15 +32,37 @@
  *   byte 30+size     : 32 to 47 bytes
  *   byte 40+size     : 48 to 60 bytes
  *   byte 4D XX       : 61+XX bytes
- *   byte 4E XXXX     : 316+XXXX bytes
+ *   byte 4E XXXX     : 317+XXXX bytes
  *   byte 4F XXXXXXXX : 65851+XXXXXXXXXX bytes
  *
+ * Ordered Bytes (sorted by content, regardless of length):
+ *   byte 0C [n bytes] 00 01 - bytes terminated by 00 01 (00 in the content is escaped as 00 FF)
+ *
  * Date:
- *   byte 50+size [n bytes] - Date from bytes as unsigned long
+ *   byte C0 [8 bytes] - Date as milliseconds since 1/1/1970 (long, with the sign bit flipped)
+ *   byte C1 [8 bytes] [4 bytes] [1 byte] - Date as seconds since 1/1/1970 (long, with the sign bit flipped),
+ *       nanoseconds and resolution (00 seconds, 01 milliseconds, 02 microseconds, 03 nanoseconds)
+ *
+ * IP address:
+ *   byte C2 [4 bytes] - IPv4 address
+ *   byte C3 [16 bytes] - IPv6 address
+ *
+ * UUID:
+ *   byte C4 [16 bytes] - UUID
+ *
+ * Enum:
+ *   byte C5 XXXX [8 bytes] - value of enum type XXXX (long, with the sign bit flipped)
+ *
+ * Duration:
+ *   byte C6 [8 bytes] - Duration in nanoseconds (long, with the sign bit flipped)
  *
  * Delta Date (see Long) :
- *   byte D8+size [n bytes] - Date as delta after 1/1/2015 (long)
- *   byte 58+size [n bytes] - Date as delta before 1/1/2015 (long)
+ *   byte D8+size [n bytes] - Date as delta in seconds after 1/1/2015 (long)
+ *   byte 58+size [n bytes] - Date as delta in seconds before 1/1/2015 (long)
+ *
+ * Delta Date in milliseconds (see Long) :
+ *   byte B8+size [n bytes] - Date as delta in milliseconds after 1/1/2015 (long)
+ *   byte B0+size [n bytes] - Date as delta in milliseconds before 1/1/2015 (long)
  *
  * Long:
  *   byte E0 - Long 0L
@@ -59,6 +82,10 @@
  *   ...
  *   byte 60 [8 bytes] - Long from bytes (negative value)
  *
+ * Big Integer (values outside of the Long range):
+ *   byte FE XXXXXXXX [n bytes] - n bytes of magnitude (positive value)
+ *   byte 08 XXXXXXXX [n bytes] - n bytes of magnitude (negative value, length and magnitude inverted)
+ *
  * Unsigned Long:
  *   byte 80 - Unsigned long 0
  *   byte 81 - Unsigned long 1
@@ -71,19 +98,40 @@
  *   byte 98 [8 bytes] Unsigned long from bytes
  *
  * Double:
- *   byte F7 - Double.NaN
- *   byte F6 - Double.POSITIVE_INFINITY
- *   byte F5 + bytes[8] - Double from bytes (positive value)
- *   byte F4 - Double +0.0
- *   byte 76 - Double -0.0
- *   byte 75 + bytes[8] - Double from bytes (negative value)
- *   byte 74 - Double.NEGATIVE_INFINITY
+ *   byte F3 - Double.NaN
+ *   byte F2 - Double.POSITIVE_INFINITY
+ *   byte F1 + bytes[8] - Double from bytes (positive value)
+ *   byte F0 - Double +0.0
+ *   byte 72 - Double -0.0
+ *   byte 71 + bytes[8] - Double from bytes (negative value, all bits inverted)
+ *   byte 70 - Double.NEGATIVE_INFINITY
+ *
+ * Legacy Date (written by older versions, only decoded):
+ *   byte 50+size [n bytes] - Date as seconds since 1/1/1970 (unsigned long, 1 to 7 bytes)
+ *   byte 58 FF [7 bytes] - Date before 1/1/1970 as seconds (long; 58 followed by other bytes is a Delta Date)
+ *
+ * Reserved (decoded as UnsupportedTagError, for forward compatibility):
+ *   bytes 50, 73-77, F4-F7
+ *
+ * Descending values:
+ *   byte 0B [value with all bytes inverted] - any of the above, sorted in reverse order
+ *
+ * Rational number (see EncodeRat, not sorted by value):
+ *   byte 09 [numerator] [denominator] - numerator and denominator encoded as Long or big integer
+ *
+ * Record terminator (see EncodeSortableRecord, not a value):
+ *   byte 01 - end of a record, sorts before any value except nil first
  *
  */
 package typedbuffer
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
 	"time"
 )
 
@@ -102,23 +150,69 @@ const (
 	BB_BOOLEAN       = 0x0E
 	BB_BOOLEAN_FALSE = BB_BOOLEAN | 0
 	BB_BOOLEAN_TRUE  = BB_BOOLEAN | 1
+	BB_BOOLEAN_ARRAY = 0x0D
 
 	/** Bytes values */
 	BB_BYTES       = 0x10
 	BB_BYTES_LEN_1 = 0x4D
 	BB_BYTES_LEN_2 = 0x4E
-	BB_BYTES_LEN_4 = 0x4E
+	BB_BYTES_LEN_4 = 0x4F
+
+	/** Descending values */
+	BB_DESC = 0x0B
+
+	/** Record terminator */
+	BB_RECORD_END = 0x01
+
+	/** Rational number values */
+	BB_RAT = 0x09
+
+	/** Ordered bytes values */
+	BB_BYTES_ORDERED = 0x0C
+	BB_ORDERED_ESC   = 0x00
+	BB_ORDERED_END   = 0x01
+	BB_ORDERED_00    = 0xFF
 
 	/** Date values */
-	BB_DATE = 0x50
+	BB_DATE      = 0xC0
+	BB_DATE_UNIT = 0xC1
+
+	/** IP address values */
+	BB_IPV4 = 0xC2
+	BB_IPV6 = 0xC3
+
+	/** UUID values */
+	BB_UUID = 0xC4
+
+	/** Enum values */
+	BB_ENUM = 0xC5
+
+	/** Duration values */
+	BB_DURATION = 0xC6
+
+	/** Tags reserved for future types (decoded as UnsupportedTagError) */
+	BB_RESERVED_NEGATIVE_DOUBLE  = 0x73
+	MAX_RESERVED_NEGATIVE_DOUBLE = 0x77
+	BB_RESERVED_POSITIVE_DOUBLE  = 0xF4
+	MAX_RESERVED_POSITIVE_DOUBLE = 0xF7
+
+	/** Legacy date values (seconds since 1970, decoded only) */
+	BB_LEGACY_DATE = 0x50
 
 	/** Compact date values */
 	BB_DELTA_DATE    = 0x58
 	BB_POSITIVE_DATE = BB_DELTA_DATE | BB_POSITIVE
 	BB_NEGATIVE_DATE = BB_DELTA_DATE | BB_NEGATIVE
 
+	/** Compact date values in milliseconds */
+	BB_DELTA_DATE_MS    = 0xB0
+	BB_POSITIVE_DATE_MS = BB_DELTA_DATE_MS | 0x08
+	BB_NEGATIVE_DATE_MS = BB_DELTA_DATE_MS
+
 	BB_DATE_MASK = 0xF8
 
+	DATE_SIGN_BIT = 1 << 63
+
 	/** Integer values */
 	BB_INT                = 0x60
 	BB_INT_POSITIVE_VALUE = BB_INT | BB_POSITIVE | 0x08
@@ -128,6 +222,9 @@ const (
 
 	BB_INT_MASK = BB_INT_POSITIVE_VALUE
 
+	MAX_INT_POSITIVE_VALUE = BB_INT_POSITIVE_VALUE | 0x07
+	MAX_INT_NEGATIVE_VALUE = BB_INT_NEGATIVE_VALUE | 0x07
+
 	SMALL_NEGATIVE_INT = -8
 	SMALL_POSITIVE_INT = +7
 	SMALL_INT_MASK     = 0x07
@@ -139,6 +236,10 @@ const (
 	MIN_SMALL_NEGATIVE = BB_SMALL_NEGATIVE | (SMALL_NEGATIVE_INT & SMALL_INT_MASK)
 	MAX_SMALL_NEGATIVE = BB_SMALL_NEGATIVE | (-1 & SMALL_INT_MASK)
 
+	/** Big integer values */
+	BB_BIG_NEGATIVE = 0x08
+	BB_BIG_POSITIVE = 0xFE
+
 	/** Double values */
 	BB_DOUBLE                   = 0x70
 	BB_DOUBLE_NAN               = (BB_DOUBLE | BB_POSITIVE) + 0x03
@@ -159,7 +260,11 @@ const (
 	SMALL_UINT_MASK = 0x1F
 
 	MIN_SMALL_UINT = BB_UINT
-	MAX_SMALL_UINT = BB_UINT + SMALL_UINT
+	MAX_SMALL_UINT = BB_UINT + SMALL_UINT // same as BB_UINT_VAR, that is never followed by a length
+
+	// values larger than SMALL_UINT are encoded as BB_UINT_VAR + number of bytes (1 to 8)
+	MIN_UINT_VAR = BB_UINT_VAR + 1
+	MAX_UINT_VAR = BB_UINT_VAR + 8
 )
 
 var (
@@ -178,21 +283,87 @@ var (
 	Bytes2 = []byte{BB_BYTES_LEN_2}
 	Bytes4 = []byte{BB_BYTES_LEN_4}
 
-	NoEncoding           = errors.New("no encoding")
-	EmptyBufferError     = errors.New("empty buffer")
-	CorruptedBufferError = errors.New("corrupted buffer")
-
-	DELTA_DATE = time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	NoEncoding            = errors.New("no encoding")
+	EmptyBufferError      = errors.New("empty buffer")
+	CorruptedBufferError  = errors.New("corrupted buffer")
+	TrailingBytesError    = errors.New("trailing bytes after value")
+	ValueOutOfRangeError  = errors.New("value out of range")
+	BytesTooLongError     = errors.New("bytes too long")
+	WriterClosedError     = errors.New("writer closed")
+	UnsupportedTagError   = errors.New("unsupported tag")
+	TooManyValuesError    = errors.New("too many values")
+	ChecksumMismatchError = errors.New("checksum mismatch")
+
+	DELTA_DATE    = time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	DELTA_DATE_MS = time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+
+	// maximum length of an encoded slice of bytes (a variable, so that tests can lower it)
+	maxBytesLen int64 = 65851 + 0xffffffff
 )
 
+//
+// A DecodeError describes where decoding a typed buffer failed.
+// It matches the underlying error (i.e. CorruptedBufferError) with errors.Is
+//
+type DecodeError struct {
+	Offset int    // offset of the failing value in the buffer
+	Tag    byte   // type tag of the failing value
+	Reason string // description of the failure
+	Err    error  // underlying error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%v at offset %d (tag %02x): %s", e.Err, e.Offset, e.Tag, e.Reason)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+//
+// Wrap error err, returned while decoding the first value in b
+//
+func newDecodeError(b []byte, err error) error {
+	if err == EmptyBufferError {
+		return err
+	}
+
+	reason := "truncated value"
+	if err == UnsupportedTagError {
+		reason = "reserved tag"
+	} else if _, terr := TypeOf(b); terr != nil {
+		reason = "invalid tag"
+	}
+
+	return &DecodeError{Offset: 0, Tag: b[0], Reason: reason, Err: err}
+}
+
+//
+// Add off to the offset of a DecodeError
+//
+func withOffset(err error, off int) error {
+	if de, ok := err.(*DecodeError); ok {
+		de.Offset += off
+	}
+
+	return err
+}
+
 //
 // Encode boolean (true / false)
 //
 func EncodeBool(b bool) []byte {
+	return AppendBool(nil, b)
+}
+
+//
+// Append encoded boolean to dst
+//
+func AppendBool(dst []byte, b bool) []byte {
 	if b {
-		return True
+		return append(dst, BB_BOOLEAN_TRUE)
 	} else {
-		return False
+		return append(dst, BB_BOOLEAN_FALSE)
 	}
 }
 
@@ -200,10 +371,17 @@ func EncodeBool(b bool) []byte {
 // Encode nil, depending on value of first
 //
 func EncodeNil(first bool) []byte {
+	return AppendNil(nil, first)
+}
+
+//
+// Append encoded nil to dst, depending on value of first
+//
+func AppendNil(dst []byte, first bool) []byte {
 	if first {
-		return NilFirst
+		return append(dst, BB_NIL_FIRST)
 	} else {
-		return NilLast
+		return append(dst, BB_NIL_LAST)
 	}
 }
 
@@ -225,20 +403,28 @@ func EncodeUint(u uint) []byte {
 // Encoce int64 value
 //
 func EncodeInt64(i int64) []byte {
+	return AppendInt64(nil, i)
+}
+
+//
+// Append encoded int64 value to dst and return the extended buffer
+// (as strconv.AppendInt, this doesn't allocate if dst has enough capacity)
+//
+func AppendInt64(dst []byte, i int64) []byte {
 	switch {
 	case i < SMALL_NEGATIVE_INT:
-		return compactInt64(uint64(i), BB_INT_NEGATIVE_VALUE)
+		return compactInt64(dst, uint64(i), BB_INT_NEGATIVE_VALUE)
 
 	case i > SMALL_POSITIVE_INT:
-		return compactInt64(uint64(i), BB_INT_POSITIVE_VALUE)
+		return compactInt64(dst, uint64(i), BB_INT_POSITIVE_VALUE)
 
 	case i >= 0:
 		// "small" positive value (0..+7)
-		return []byte{BB_SMALL_POSITIVE + byte(i&SMALL_INT_MASK)}
+		return append(dst, BB_SMALL_POSITIVE+byte(i&SMALL_INT_MASK))
 
 	default:
 		// "small" negative value (-8..-1)
-		return []byte{BB_SMALL_NEGATIVE + byte(i&SMALL_INT_MASK)}
+		return append(dst, BB_SMALL_NEGATIVE+byte(i&SMALL_INT_MASK))
 	}
 }
 
@@ -246,18 +432,24 @@ func EncodeInt64(i int64) []byte {
 // Encode uint64
 //
 func EncodeUint64(u uint64) []byte {
+	return AppendUint64(nil, u)
+}
+
+//
+// Append encoded uint64 value to dst and return the extended buffer
+//
+func AppendUint64(dst []byte, u uint64) []byte {
 	if u <= SMALL_UINT {
-		return []byte{BB_UINT + byte(u)}
+		return append(dst, BB_UINT+byte(u))
 	} else {
-		return compactUint64(u, BB_UINT_VAR)
+		return compactUint64(dst, u, BB_UINT_VAR)
 	}
 }
 
-func compactInt64(v uint64, typ byte) []byte {
-	bb := make([]byte, 0, 8)
+func compactInt64(bb []byte, v uint64, typ byte) []byte {
 	bits := 64 /* size of int64 */ - 8
 
-	if (typ & BB_TYPE_MASK) == BB_INT_NEGATIVE_VALUE { // negative value
+	if int64(v) < 0 { // negative value
 		for ; bits > 0; bits -= 8 {
 			if ((v >> uint(bits)) & 0xff) != 0xff {
 				break
@@ -296,8 +488,7 @@ func uncompactInt64(bb []byte, positive bool) int64 {
 	return l
 }
 
-func compactUint64(u uint64, typ byte) []byte {
-	bb := make([]byte, 0, 8)
+func compactUint64(bb []byte, u uint64, typ byte) []byte {
 	bits := 64 /* size of uint64 */ - 8
 
 	for ; bits > 0; bits -= 8 {
@@ -326,52 +517,267 @@ func uncompactUint64(bb []byte) uint64 {
 }
 
 //
-// Encode slice of bytes
+// Encode float64 value
+//
+func EncodeFloat64(f float64) []byte {
+	return appendFloat64(nil, f)
+}
+
+//
+// Append encoded float64 value to dst.
+// Special values are encoded as a single byte, finite values as the IEEE 754 bits
+// (inverted for negative values, so that the encoding sorts numerically)
+//
+func appendFloat64(dst []byte, f float64) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(dst, BB_DOUBLE_NAN)
+
+	case math.IsInf(f, 1):
+		return append(dst, BB_DOUBLE_POSITIVE_INFINITY)
+
+	case math.IsInf(f, -1):
+		return append(dst, BB_DOUBLE_NEGATIVE_INFINITY)
+
+	case f == 0 && math.Signbit(f):
+		return append(dst, BB_DOUBLE_NEGATIVE_ZERO)
+
+	case f == 0:
+		return append(dst, BB_DOUBLE_POSITIVE_ZERO)
+
+	case f > 0:
+		return appendFixed64(append(dst, BB_DOUBLE_POSITIVE_VALUE), math.Float64bits(f))
+
+	default:
+		return appendFixed64(append(dst, BB_DOUBLE_NEGATIVE_VALUE), ^math.Float64bits(f))
+	}
+}
+
+//
+// Append u to dst as 8 big endian bytes
+//
+func appendFixed64(dst []byte, u uint64) []byte {
+	for bits := 56; bits >= 0; bits -= 8 {
+		dst = append(dst, byte(u>>uint(bits)))
+	}
+
+	return dst
+}
+
+//
+// Encode slice of bytes.
+// Panics if the slice is too long to be encoded (see EncodeBytesSafe)
 //
 func EncodeBytes(bb []byte) []byte {
-	l := len(bb)
+	return AppendBytes(make([]byte, 0, bytesLen(len(bb))), bb)
+}
+
+//
+// Encode slice of bytes, as EncodeBytes, but returns BytesTooLongError
+// instead of panicking if the slice is too long to be encoded
+//
+func EncodeBytesSafe(bb []byte) ([]byte, error) {
+	if err := checkBytesLen(len(bb)); err != nil {
+		return nil, err
+	}
+
+	return EncodeBytes(bb), nil
+}
+
+//
+// Return BytesTooLongError if a slice of l bytes is too long to be encoded
+//
+func checkBytesLen(l int) error {
+	if int64(l) > maxBytesLen {
+		return BytesTooLongError
+	}
+
+	return nil
+}
+
+//
+// Append encoded slice of bytes to dst and return the extended buffer.
+// Panics if the slice is too long to be encoded (see EncodeBytesSafe)
+//
+func AppendBytes(dst []byte, bb []byte) []byte {
+	return append(appendBytesHeader(dst, len(bb)), bb...)
+}
+
+//
+// Append encoded string to dst
+//
+func appendString(dst []byte, s string) []byte {
+	return append(appendBytesHeader(dst, len(s)), s...)
+}
+
+//
+// Append type tag and length for a slice of l bytes to dst
+//
+func appendBytesHeader(dst []byte, l int) []byte {
 
 	switch {
 	case l <= 60:
-		b := []byte{BB_BYTES | byte(l)}
-		return append(b, bb...)
+		dst = append(dst, BB_BYTES+byte(l))
 
 	case l <= (61 + 0xff):
 		l -= 61
-		b := append(Bytes1, byte(l))
-		return append(b, bb...)
+		dst = append(dst, BB_BYTES_LEN_1, byte(l))
 
 	case l <= (317 + 0xffff):
 		l -= 317
+		dst = append(dst, BB_BYTES_LEN_2, byte(l>>8), byte(l>>0))
 
-		b := append(Bytes2, byte(l>>8), byte(l>>0))
-		return append(b, bb...)
-
-	case l <= (65851 + 0xffffffff):
+	case int64(l) <= (65851 + 0xffffffff):
 		l -= 65851
-
-		b := append(Bytes4, byte(l>>24), byte(l>>16), byte(l>>8), byte(l>>0))
-		return append(b, bb...)
+		dst = append(dst, BB_BYTES_LEN_4, byte(l>>24), byte(l>>16), byte(l>>8), byte(l>>0))
 
 	default:
 		panic("slice too long")
 	}
+
+	return dst
+}
+
+//
+// Encode slice of bytes so that the natural order of the encoded buffers
+// matches the order of the content, independently of the length
+//
+func EncodeBytesOrdered(bb []byte) []byte {
+	return appendBytesOrdered(make([]byte, 0, len(bb)+3), bb)
+}
+
+//
+// Append slice of bytes to dst, encoded as ordered bytes
+//
+func appendBytesOrdered(dst []byte, bb []byte) []byte {
+	dst = append(dst, BB_BYTES_ORDERED)
+
+	for _, c := range bb {
+		if c == BB_ORDERED_ESC {
+			dst = append(dst, BB_ORDERED_ESC, BB_ORDERED_00)
+		} else {
+			dst = append(dst, c)
+		}
+	}
+
+	return append(dst, BB_ORDERED_ESC, BB_ORDERED_END)
+}
+
+//
+// Return the length of ordered bytes content, including the terminator
+// (all bytes are xor'ed with inv, see valueLen)
+//
+func orderedLen(b []byte, inv byte) (int, error) {
+	for i := 0; i < len(b); i++ {
+		if b[i]^inv != BB_ORDERED_ESC {
+			continue
+		}
+
+		if i+1 >= len(b) {
+			break
+		}
+
+		switch b[i+1] ^ inv {
+		case BB_ORDERED_END:
+			return i + 2, nil
+
+		case BB_ORDERED_00:
+			i++
+
+		default:
+			return 0, CorruptedBufferError
+		}
+	}
+
+	return 0, CorruptedBufferError
+}
+
+//
+// Decode ordered bytes content (after the tag). Returns decoded bytes and remaining buffer
+//
+func decodeOrdered(b []byte) ([]byte, []byte, error) {
+	n, err := orderedLen(b, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bb := make([]byte, 0, n-2)
+
+	// copy the runs between escaped bytes (the escape byte is the unescaped 00)
+	for content := b[:n-2]; len(content) > 0; {
+		i := bytes.IndexByte(content, BB_ORDERED_ESC)
+		if i < 0 {
+			bb = append(bb, content...)
+			break
+		}
+
+		bb = append(bb, content[:i+1]...)
+		content = content[i+2:]
+	}
+
+	return bb, b[n:], nil
+}
+
+//
+// Encode string (same encoding as slice of bytes)
+//
+func EncodeString(s string) []byte {
+	return appendString(make([]byte, 0, bytesLen(len(s))), s)
 }
 
 //
-// Encode Time
+// Encode Time (as compacted delta in milliseconds from 2015-01-01)
 //
 func EncodeTime(t time.Time) []byte {
-	i := t.Unix()
-	return compactUint64(uint64(i), BB_DATE)
+	return appendTime(nil, t)
 }
 
 //
-// Encode Time as delta from 2015-01-01
+// Encode Time as delta in seconds from 2015-01-01 (sub-second precision is truncated)
 //
 func EncodeTimeDelta(t time.Time) []byte {
 	i := t.Unix() - DELTA_DATE
-	return compactInt64(uint64(i), BB_DELTA_DATE)
+	if i < 0 {
+		return compactInt64(nil, uint64(i), BB_NEGATIVE_DATE)
+	}
+
+	return compactInt64(nil, uint64(i), BB_POSITIVE_DATE)
+}
+
+//
+// Encode Time as 8 bytes of milliseconds since 1/1/1970, with the sign bit flipped.
+// Unlike the compact delta encoding all dates have the same length, but fixed and compact dates
+// don't sort with each other with bytes.Compare (they do with Compare)
+//
+func EncodeDateFixed(t time.Time) []byte {
+	return appendFixed64(append(make([]byte, 0, 9), BB_DATE), uint64(t.UnixMilli())^DATE_SIGN_BIT)
+}
+
+//
+// Append encoded Time to dst
+//
+func appendTime(dst []byte, t time.Time) []byte {
+	i := t.UnixMilli() - DELTA_DATE_MS
+	if i < 0 {
+		return compactInt64(dst, uint64(i), BB_NEGATIVE_DATE_MS)
+	}
+
+	return compactInt64(dst, uint64(i), BB_POSITIVE_DATE_MS)
+}
+
+//
+// Encode Duration as 8 bytes of nanoseconds with a dedicated tag, so that it's decoded as a time.Duration.
+// Negative durations sort before positive ones
+//
+func EncodeDuration(d time.Duration) []byte {
+	return appendDuration(make([]byte, 0, 9), d)
+}
+
+//
+// Append encoded Duration to dst
+//
+func appendDuration(dst []byte, d time.Duration) []byte {
+	return appendFixed64(append(dst, BB_DURATION), uint64(d)^DATE_SIGN_BIT)
 }
 
 //
@@ -383,43 +789,157 @@ func Encode(values ...interface{}) ([]byte, error) {
 }
 
 func EncodeNils(nilFirst bool, values ...interface{}) ([]byte, error) {
-	b := []byte{}
+	if len(values) == 1 {
+		if c, ok := singleByte(values[0], nilFirst); ok {
+			return []byte{c}, nil
+		}
+	}
+
+	return appendValues([]byte{}, nilFirst, values)
+}
+
+//
+// Encode one or more values, as Encode, but computing the encoded length first
+// so that the result is allocated only once
+//
+func EncodePacked(values ...interface{}) ([]byte, error) {
+	n, err := EncodedLenAll(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendValues(make([]byte, 0, n), true, values)
+}
 
+//
+// Append encoded values to b
+//
+func appendValues(b []byte, nilFirst bool, values []interface{}) ([]byte, error) {
 	for _, v := range values {
 		if v == nil {
-			b = append(b, EncodeNil(nilFirst)...)
+			b = AppendNil(b, nilFirst)
 			continue
 		}
 
 		switch t := v.(type) {
 		case bool:
-			b = append(b, EncodeBool(t)...)
+			b = AppendBool(b, t)
 
 		case int:
-			b = append(b, EncodeInt(t)...)
+			b = AppendInt64(b, int64(t))
+
+		case int8:
+			b = AppendInt64(b, int64(t))
+
+		case int16:
+			b = AppendInt64(b, int64(t))
+
+		case int32: // and rune
+			b = AppendInt64(b, int64(t))
 
 		case int64:
-			b = append(b, EncodeInt64(t)...)
+			b = AppendInt64(b, t)
+
+		case uint:
+			b = AppendUint64(b, uint64(t))
+
+		case uint8: // and byte ([]byte is encoded as bytes)
+			b = AppendUint64(b, uint64(t))
+
+		case uint16:
+			b = AppendUint64(b, uint64(t))
+
+		case uint32:
+			b = AppendUint64(b, uint64(t))
 
 		case uint64:
-			b = append(b, EncodeUint64(t)...)
+			b = AppendUint64(b, t)
 
 		case []uint64:
 			for _, u := range t {
-				b = append(b, EncodeUint64(u)...)
+				b = AppendUint64(b, u)
+			}
+
+		case []int64:
+			for _, i := range t {
+				b = AppendInt64(b, i)
+			}
+
+		case []int:
+			for _, i := range t {
+				b = AppendInt64(b, int64(i))
 			}
 
 		case []byte:
-			b = append(b, EncodeBytes(t)...)
+			if err := checkBytesLen(len(t)); err != nil {
+				return nil, err
+			}
+			b = AppendBytes(b, t)
 
 		case string:
-			b = append(b, EncodeBytes([]byte(t))...)
+			if err := checkBytesLen(len(t)); err != nil {
+				return nil, err
+			}
+			b = appendString(b, t)
+
+		case []bool:
+			if err := checkBoolsLen(len(t)); err != nil {
+				return nil, err
+			}
+			b = appendBools(b, t)
+
+		case []string:
+			for _, s := range t {
+				if err := checkBytesLen(len(s)); err != nil {
+					return nil, err
+				}
+				b = appendString(b, s)
+			}
+
+		case float32:
+			b = appendFloat64(b, float64(t))
+
+		case float64:
+			b = appendFloat64(b, t)
 
 		case time.Time:
-			b = append(b, EncodeTime(t)...)
+			b = appendTime(b, t)
+
+		case time.Duration:
+			b = appendDuration(b, t)
+
+		case NilValue:
+			b = AppendNil(b, t.First)
+
+		case [16]byte:
+			b = AppendUUID(b, t)
+
+		case EnumValue:
+			b = appendEnum(b, t)
+
+		case net.IP:
+			if t == nil {
+				b = AppendNil(b, nilFirst)
+			} else if b = appendIP(b, t); b == nil {
+				return nil, fmt.Errorf("%w: invalid IP address %v", NoEncoding, t)
+			}
+
+		case *big.Int:
+			if t == nil {
+				b = AppendNil(b, nilFirst)
+			} else {
+				b = appendBigInt(b, t)
+			}
+
+		case *big.Rat:
+			if t == nil {
+				b = AppendNil(b, nilFirst)
+			} else {
+				b = appendRat(b, t)
+			}
 
 		default:
-			return nil, NoEncoding
+			return nil, unsupportedType(v)
 		}
 	}
 
@@ -427,116 +947,1205 @@ func EncodeNils(nilFirst bool, values ...interface{}) ([]byte, error) {
 }
 
 //
-// Decode first value in typed buffer. Returns decoded value and remaining buffer
+// Return the encoding of v if it is one of the common values encoded as a single byte
+// (nil, bools, small ints and small uints), so that they can skip the full type switch
 //
-func Decode(b []byte) (interface{}, []byte, error) {
-	if len(b) == 0 {
-		return nil, nil, EmptyBufferError
-	}
-
-	k := b[0]
-	next := b[1:]
-
-	switch {
-	case k == BB_NIL_FIRST || k == BB_NIL_LAST:
-		return nil, next, nil
-
-	case k == BB_BOOLEAN_FALSE:
-		return false, next, nil
-
-	case k == BB_BOOLEAN_TRUE:
-		return true, next, nil
-
-	case k >= BB_BYTES && k < BB_BYTES_LEN_1:
-		k -= BB_BYTES
-		if len(next) < int(k) {
-			return nil, nil, CorruptedBufferError
+func singleByte(v interface{}, nilFirst bool) (byte, bool) {
+	switch t := v.(type) {
+	case nil:
+		if nilFirst {
+			return BB_NIL_FIRST, true
 		}
-		return next[0:k], next[k:], nil
+		return BB_NIL_LAST, true
 
-	case k == BB_BYTES_LEN_1:
-		if len(next) < 1 {
-			return nil, nil, CorruptedBufferError
-		}
-		k, next = next[0], next[1:]
-		n := int(k) + 62
-		if len(next) < int(n) {
-			return nil, nil, CorruptedBufferError
+	case bool:
+		if t {
+			return BB_BOOLEAN_TRUE, true
 		}
-		return next[0:n], next[n:], nil
+		return BB_BOOLEAN_FALSE, true
 
-	case k == BB_BYTES_LEN_2:
-		if len(next) < 2 {
-			return nil, nil, CorruptedBufferError
+	case int:
+		if t >= SMALL_NEGATIVE_INT && t <= SMALL_POSITIVE_INT {
+			return smallInt(int64(t)), true
 		}
 
-		k1, k2, next := next[0], next[1], next[2:]
-		n := int(k1)*256 + int(k2) + 318
-		if len(next) < int(n) {
-			return nil, nil, CorruptedBufferError
+	case int64:
+		if t >= SMALL_NEGATIVE_INT && t <= SMALL_POSITIVE_INT {
+			return smallInt(t), true
 		}
-		return next[0:n], next[n:], nil
 
-	case k >= MIN_SMALL_POSITIVE && k <= MAX_SMALL_POSITIVE:
-		return int64(k & SMALL_INT_MASK), next, nil
+	case uint64:
+		if t <= SMALL_UINT {
+			return BB_UINT + byte(t), true
+		}
+	}
 
-	case k >= MIN_SMALL_NEGATIVE && k <= MAX_SMALL_NEGATIVE:
-		return int64(k&SMALL_INT_MASK) | SMALL_NEG_MASK, next, nil
+	return 0, false
+}
 
-	case (k & BB_INT_MASK) == BB_INT_POSITIVE_VALUE:
-		n := int(k&7) + 1
+//
+// Return the encoding of small int i (-8..+7)
+//
+func smallInt(i int64) byte {
+	if i >= 0 {
+		return BB_SMALL_POSITIVE + byte(i&SMALL_INT_MASK)
+	}
+
+	return BB_SMALL_NEGATIVE + byte(i&SMALL_INT_MASK)
+}
+
+//
+// Return an error wrapping NoEncoding that names the type of v
+//
+func unsupportedType(v interface{}) error {
+	return fmt.Errorf("%w: unsupported type %T", NoEncoding, v)
+}
+
+//
+// Return the number of bytes needed to store v once compacted
+// (leading zero bytes, or leading 0xff bytes for negative values, are dropped)
+//
+func compactLen(v uint64, negative bool) int {
+	n := 8
+
+	if negative {
+		for ; n > 1 && (v>>uint(8*(n-1)))&0xff == 0xff; n-- {
+		}
+	} else {
+		for ; n > 1 && (v>>uint(8*(n-1))) == 0; n-- {
+		}
+	}
+
+	return n
+}
+
+//
+// Return the number of bytes used to encode int64 value i
+//
+func int64Len(i int64) int {
+	if i >= SMALL_NEGATIVE_INT && i <= SMALL_POSITIVE_INT {
+		return 1
+	}
+
+	return 1 + compactLen(uint64(i), i < 0)
+}
+
+//
+// Return the number of bytes used to encode uint64 value u
+//
+func uint64Len(u uint64) int {
+	if u <= SMALL_UINT {
+		return 1
+	}
+
+	return 1 + compactLen(u, false)
+}
+
+//
+// Return the number of bytes used to encode float64 value f
+//
+func float64Len(f float64) int {
+	if f == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 1
+	}
+
+	return 9
+}
+
+//
+// Return the number of bytes used to encode a slice of l bytes
+//
+func bytesLen(l int) int {
+	switch {
+	case l <= 60:
+		return 1 + l
+
+	case l <= (61 + 0xff):
+		return 2 + l
+
+	case l <= (317 + 0xffff):
+		return 3 + l
+
+	default:
+		return 5 + l
+	}
+}
+
+//
+// Return the number of bytes Encode would produce for value v, without encoding it
+//
+func EncodedLen(v interface{}) (int, error) {
+	if v == nil {
+		return 1, nil
+	}
+
+	switch t := v.(type) {
+	case bool:
+		return 1, nil
+
+	case int:
+		return int64Len(int64(t)), nil
+
+	case int8:
+		return int64Len(int64(t)), nil
+
+	case int16:
+		return int64Len(int64(t)), nil
+
+	case int32:
+		return int64Len(int64(t)), nil
+
+	case int64:
+		return int64Len(t), nil
+
+	case uint:
+		return uint64Len(uint64(t)), nil
+
+	case uint8:
+		return uint64Len(uint64(t)), nil
+
+	case uint16:
+		return uint64Len(uint64(t)), nil
+
+	case uint32:
+		return uint64Len(uint64(t)), nil
+
+	case uint64:
+		return uint64Len(t), nil
+
+	case []uint64:
+		l := 0
+		for _, u := range t {
+			l += uint64Len(u)
+		}
+		return l, nil
+
+	case []int64:
+		l := 0
+		for _, i := range t {
+			l += int64Len(i)
+		}
+		return l, nil
+
+	case []int:
+		l := 0
+		for _, i := range t {
+			l += int64Len(int64(i))
+		}
+		return l, nil
+
+	case []byte:
+		return bytesLen(len(t)), nil
+
+	case string:
+		return bytesLen(len(t)), nil
+
+	case []string:
+		l := 0
+		for _, s := range t {
+			l += bytesLen(len(s))
+		}
+		return l, nil
+
+	case []bool:
+		return boolsLen(len(t)), nil
+
+	case float32:
+		return float64Len(float64(t)), nil
+
+	case float64:
+		return float64Len(t), nil
+
+	case time.Time:
+		i := t.UnixMilli() - DELTA_DATE_MS
+		return 1 + compactLen(uint64(i), i < 0), nil
+
+	case time.Duration:
+		return 9, nil
+
+	case NilValue:
+		return 1, nil
+
+	case [16]byte:
+		return 17, nil
+
+	case EnumValue:
+		return 11, nil
+
+	case net.IP:
+		switch {
+		case t == nil:
+			return 1, nil
+		case t.To4() != nil:
+			return 5, nil
+		case len(t) == net.IPv6len:
+			return 17, nil
+		default:
+			return 0, fmt.Errorf("%w: invalid IP address %v", NoEncoding, t)
+		}
+
+	case *big.Int:
+		if t == nil {
+			return 1, nil
+		}
+		if t.IsInt64() {
+			return int64Len(t.Int64()), nil
+		}
+		return 5 + (t.BitLen()+7)/8, nil
+
+	case *big.Rat:
+		if t == nil {
+			return 1, nil
+		}
+		return len(appendRat(nil, t)), nil
+
+	default:
+		return 0, unsupportedType(v)
+	}
+}
+
+//
+// Return the number of bytes Encode would produce for all values
+//
+func EncodedLenAll(values ...interface{}) (int, error) {
+	l := 0
+
+	for _, v := range values {
+		n, err := EncodedLen(v)
+		if err != nil {
+			return 0, err
+		}
+
+		l += n
+	}
+
+	return l, nil
+}
+
+//
+// Decode first value in typed buffer. Returns decoded value and remaining buffer.
+// Signed integers are always returned as int64 and unsigned integers as uint64,
+// whatever type they were encoded from (see DecodeNumber to get either as int64).
+// Note that decoded byte slices share memory with b (use DecodeCopy if b is going to be reused)
+//
+func Decode(b []byte) (interface{}, []byte, error) {
+	v, next, err := decode(b)
+	if err != nil {
+		return nil, nil, newDecodeError(b, err)
+	}
+
+	return v, next, nil
+}
+
+//
+// Decode first value in typed buffer, as Decode, but decoded byte slices are copied
+// so that they are not affected by changes to b
+//
+func DecodeCopy(b []byte) (interface{}, []byte, error) {
+	if len(b) > 0 {
+		if sb, n, ok := bytesValue(b); ok {
+			cb := make([]byte, len(sb))
+			copy(cb, sb)
+			return cb, b[n:], nil
+		}
+	}
+
+	v, next, err := Decode(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cloneValue(v), next, nil
+}
+
+//
+// Return a copy of decoded values vs where byte slices (and IP addresses) are copied,
+// so that the result can be retained after the buffer they were decoded from is reused.
+// Strings are immutable and are returned as they are
+//
+func CloneValues(vs []interface{}) []interface{} {
+	if vs == nil {
+		return nil
+	}
+
+	res := make([]interface{}, len(vs))
+	for i, v := range vs {
+		res[i] = cloneValue(v)
+	}
+
+	return res
+}
+
+//
+// Return a copy of v if it shares memory with the decoded buffer
+//
+func cloneValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case []byte:
+		cb := make([]byte, len(t))
+		copy(cb, t)
+		return cb
+
+	case net.IP:
+		ip := make(net.IP, len(t))
+		copy(ip, t)
+		return ip
+	}
+
+	return v
+}
+
+func decode(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, EmptyBufferError
+	}
+
+	k := b[0]
+	next := b[1:]
+
+	switch {
+	case k == BB_NIL_FIRST || k == BB_NIL_LAST:
+		return nil, next, nil
+
+	case k == BB_BOOLEAN_FALSE:
+		return false, next, nil
+
+	case k == BB_BOOLEAN_TRUE:
+		return true, next, nil
+
+	case k == BB_BOOLEAN_ARRAY:
+		return decodeBools(next)
+
+	case k == BB_DESC:
+		return decodeDesc(b)
+
+	case k == BB_BYTES_ORDERED:
+		return decodeOrdered(next)
+
+	case k == BB_RAT:
+		return decodeRat(next)
+
+	case k >= BB_BYTES && k <= BB_BYTES_LEN_4:
+		h := headerLen(k)
+		if len(next) < h {
+			return nil, nil, CorruptedBufferError
+		}
+
+		n, err := payloadLen(k, next[0:h])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		next = next[h:]
+		if len(next) < n {
+			return nil, nil, CorruptedBufferError
+		}
+		return next[0:n], next[n:], nil
+
+	case k >= MIN_SMALL_POSITIVE && k <= MAX_SMALL_POSITIVE:
+		return int64(k & SMALL_INT_MASK), next, nil
+
+	case k >= MIN_SMALL_NEGATIVE && k <= MAX_SMALL_NEGATIVE:
+		return int64(k&SMALL_INT_MASK) | SMALL_NEG_MASK, next, nil
+
+	case k >= BB_INT_POSITIVE_VALUE && k <= MAX_INT_POSITIVE_VALUE:
+		n := int(k&7) + 1
 		if len(next) < n {
 			return nil, nil, CorruptedBufferError
 		}
 		return uncompactInt64(next[0:n], true), next[n:], nil
 
-	case (k & BB_INT_MASK) == BB_INT_NEGATIVE_VALUE:
-		n := 8 - int(k&7)
-		if len(next) < n {
-			return nil, nil, CorruptedBufferError
-		}
-		return uncompactInt64(next[0:n], false), next[n:], nil
+	case k >= BB_INT_NEGATIVE_VALUE && k <= MAX_INT_NEGATIVE_VALUE:
+		n := 8 - int(k&7)
+		if len(next) < n {
+			return nil, nil, CorruptedBufferError
+		}
+		return uncompactInt64(next[0:n], false), next[n:], nil
+
+	case k >= MIN_SMALL_UINT && k <= MAX_SMALL_UINT:
+		return uint64(k - BB_UINT), next, nil
+
+	case k >= MIN_UINT_VAR && k <= MAX_UINT_VAR:
+		n := int(k - BB_UINT_VAR)
+		if len(next) < n {
+			return nil, nil, CorruptedBufferError
+		}
+		return uncompactUint64(next[0:n]), next[n:], nil
+
+	case k == BB_BIG_POSITIVE || k == BB_BIG_NEGATIVE:
+		return decodeBigInt(k, next)
+
+	case k == BB_DOUBLE_NAN:
+		return math.NaN(), next, nil
+
+	case k == BB_DOUBLE_POSITIVE_INFINITY:
+		return math.Inf(1), next, nil
+
+	case k == BB_DOUBLE_NEGATIVE_INFINITY:
+		return math.Inf(-1), next, nil
+
+	case k == BB_DOUBLE_POSITIVE_ZERO:
+		return 0.0, next, nil
+
+	case k == BB_DOUBLE_NEGATIVE_ZERO:
+		return math.Copysign(0, -1), next, nil
+
+	case k == BB_DOUBLE_POSITIVE_VALUE:
+		if len(next) < 8 {
+			return nil, nil, CorruptedBufferError
+		}
+		return math.Float64frombits(uncompactUint64(next[0:8])), next[8:], nil
+
+	case k == BB_DOUBLE_NEGATIVE_VALUE:
+		if len(next) < 8 {
+			return nil, nil, CorruptedBufferError
+		}
+		return math.Float64frombits(^uncompactUint64(next[0:8])), next[8:], nil
+
+	case k == BB_DATE:
+		if len(next) < 8 {
+			return nil, nil, CorruptedBufferError
+		}
+
+		t := uncompactUint64(next[0:8]) ^ DATE_SIGN_BIT
+		return time.UnixMilli(int64(t)).UTC(), next[8:], nil
+
+	case k == BB_DURATION:
+		if len(next) < 8 {
+			return nil, nil, CorruptedBufferError
+		}
+
+		d := uncompactUint64(next[0:8]) ^ DATE_SIGN_BIT
+		return time.Duration(d), next[8:], nil
+
+	case k == BB_IPV4 || k == BB_IPV6:
+		n := payloadLenIP(k)
+		if len(next) < n {
+			return nil, nil, CorruptedBufferError
+		}
+		return net.IP(next[0:n]), next[n:], nil
+
+	case k == BB_ENUM:
+		if len(next) < 10 {
+			return nil, nil, CorruptedBufferError
+		}
+
+		e := EnumValue{
+			Type:  uint16(next[0])<<8 | uint16(next[1]),
+			Value: int64(uncompactUint64(next[2:10]) ^ DATE_SIGN_BIT),
+		}
+		return e, next[10:], nil
+
+	case k == BB_UUID:
+		if len(next) < 16 {
+			return nil, nil, CorruptedBufferError
+		}
+
+		var u [16]byte
+		copy(u[:], next)
+		return u, next[16:], nil
+
+	case k == BB_DATE_UNIT:
+		t, _, next, err := decodeTimeUnit(next)
+		if err != nil {
+			return nil, nil, err
+		}
+		return t, next, nil
+
+	case legacyDate(k, next):
+		n := legacyDateLen(k)
+		if len(next) < n {
+			return nil, nil, CorruptedBufferError
+		}
+
+		t := uncompactUint64(next[0:n])
+		return time.Unix(int64(t), 0).UTC(), next[n:], nil
+
+	case (k & BB_DATE_MASK) == BB_POSITIVE_DATE:
+		n := int(k&7) + 1
+		if len(next) < n {
+			return nil, nil, CorruptedBufferError
+		}
+
+		t := uncompactInt64(next[0:n], true)
+		return time.Unix(t+DELTA_DATE, 0).UTC(), next[n:], nil
+
+	case (k & BB_DATE_MASK) == BB_NEGATIVE_DATE:
+		n := 8 - int(k&7)
+		if len(next) < n {
+			return nil, nil, CorruptedBufferError
+		}
+
+		t := uncompactInt64(next[0:n], false)
+		return time.Unix(t+DELTA_DATE, 0).UTC(), next[n:], nil
+
+	case (k & BB_DATE_MASK) == BB_POSITIVE_DATE_MS:
+		n := int(k&7) + 1
+		if len(next) < n {
+			return nil, nil, CorruptedBufferError
+		}
+
+		t := uncompactInt64(next[0:n], true)
+		return time.UnixMilli(t + DELTA_DATE_MS).UTC(), next[n:], nil
+
+	case (k & BB_DATE_MASK) == BB_NEGATIVE_DATE_MS:
+		n := 8 - int(k&7)
+		if len(next) < n {
+			return nil, nil, CorruptedBufferError
+		}
+
+		t := uncompactInt64(next[0:n], false)
+		return time.UnixMilli(t + DELTA_DATE_MS).UTC(), next[n:], nil
+
+	case reservedTag(k):
+		return nil, nil, UnsupportedTagError
+
+	default:
+		return nil, nil, CorruptedBufferError
+	}
+}
+
+//
+// Return the number of length bytes that follow tag k
+//
+func headerLen(k byte) int {
+	switch k {
+	case BB_BYTES_LEN_1:
+		return 1
+
+	case BB_BYTES_LEN_2:
+		return 2
+
+	case BB_BYTES_LEN_4, BB_BIG_POSITIVE, BB_BIG_NEGATIVE, BB_BOOLEAN_ARRAY:
+		return 4
+
+	default:
+		return 0
+	}
+}
+
+//
+// Return the number of payload bytes that follow tag k and its length header h
+//
+func payloadLen(k byte, h []byte) (int, error) {
+	switch {
+	case k == BB_NIL_FIRST || k == BB_NIL_LAST:
+		return 0, nil
+
+	case k == BB_BOOLEAN_FALSE || k == BB_BOOLEAN_TRUE:
+		return 0, nil
+
+	case k == BB_BOOLEAN_ARRAY:
+		n := uint64(h[0])<<24 + uint64(h[1])<<16 + uint64(h[2])<<8 + uint64(h[3])
+		return int((n + 7) / 8), nil
+
+	case k >= BB_BYTES && k < BB_BYTES_LEN_1:
+		return int(k - BB_BYTES), nil
+
+	case k == BB_BYTES_LEN_1:
+		return int(h[0]) + 61, nil
+
+	case k == BB_BYTES_LEN_2:
+		return int(h[0])<<8 + int(h[1]) + 317, nil
+
+	case k == BB_BYTES_LEN_4:
+		n := uint64(h[0])<<24 + uint64(h[1])<<16 + uint64(h[2])<<8 + uint64(h[3]) + 65851
+		if n > math.MaxInt {
+			return 0, CorruptedBufferError
+		}
+		return int(n), nil
+
+	case k >= MIN_SMALL_POSITIVE && k <= MAX_SMALL_POSITIVE:
+		return 0, nil
+
+	case k >= MIN_SMALL_NEGATIVE && k <= MAX_SMALL_NEGATIVE:
+		return 0, nil
+
+	case k >= BB_INT_POSITIVE_VALUE && k <= MAX_INT_POSITIVE_VALUE:
+		return int(k&7) + 1, nil
+
+	case k >= BB_INT_NEGATIVE_VALUE && k <= MAX_INT_NEGATIVE_VALUE:
+		return 8 - int(k&7), nil
+
+	case k >= MIN_SMALL_UINT && k <= MAX_SMALL_UINT:
+		return 0, nil
+
+	case k >= MIN_UINT_VAR && k <= MAX_UINT_VAR:
+		return int(k - BB_UINT_VAR), nil
+
+	case k == BB_DATE || k == BB_DURATION:
+		return 8, nil
+
+	case k == BB_DATE_UNIT:
+		return 13, nil
+
+	case k == BB_IPV4 || k == BB_IPV6:
+		return payloadLenIP(k), nil
+
+	case k == BB_UUID:
+		return 16, nil
+
+	case k == BB_ENUM:
+		return 10, nil
+
+	case k == BB_DOUBLE_POSITIVE_VALUE || k == BB_DOUBLE_NEGATIVE_VALUE:
+		return 8, nil
+
+	case k >= BB_DOUBLE_NEGATIVE_INFINITY && k <= BB_DOUBLE_NEGATIVE_ZERO:
+		return 0, nil
+
+	case k >= BB_DOUBLE_POSITIVE_ZERO && k <= BB_DOUBLE_NAN:
+		return 0, nil
+
+	case k == BB_BIG_POSITIVE:
+		return bigLen(h[0], h[1], h[2], h[3])
+
+	case k == BB_BIG_NEGATIVE:
+		return bigLen(^h[0], ^h[1], ^h[2], ^h[3])
+
+	case (k&BB_DATE_MASK) == BB_LEGACY_DATE && k != BB_LEGACY_DATE:
+		return legacyDateLen(k), nil
+
+	case (k&BB_DATE_MASK) == BB_POSITIVE_DATE || (k&BB_DATE_MASK) == BB_POSITIVE_DATE_MS:
+		return int(k&7) + 1, nil
+
+	case (k&BB_DATE_MASK) == BB_NEGATIVE_DATE || (k&BB_DATE_MASK) == BB_NEGATIVE_DATE_MS:
+		return 8 - int(k&7), nil
+
+	case reservedTag(k):
+		return 0, UnsupportedTagError
+
+	default:
+		return 0, CorruptedBufferError
+	}
+}
+
+//
+// Return true if tag k, followed by payload b, is a legacy date: 51 to 57 (seconds since 1970 in 1 to 7 bytes),
+// or 58 followed by FF (a date before 1970 in 8 bytes, that the delta date encoding never writes)
+//
+func legacyDate(k byte, b []byte) bool {
+	switch {
+	case k > BB_LEGACY_DATE && k < BB_NEGATIVE_DATE:
+		return true
+
+	case k == BB_NEGATIVE_DATE:
+		return len(b) > 0 && b[0] == 0xFF
+
+	default:
+		return false
+	}
+}
+
+//
+// Return the payload length of legacy date tag k (tag 58 has 8 bytes of payload, as a delta date)
+//
+func legacyDateLen(k byte) int {
+	return int(k - BB_LEGACY_DATE)
+}
+
+//
+// Return true if k is a tag reserved for types that are not supported (yet),
+// as opposed to a tag that is never valid
+//
+func reservedTag(k byte) bool {
+	switch {
+	case k >= BB_RESERVED_NEGATIVE_DOUBLE && k <= MAX_RESERVED_NEGATIVE_DOUBLE:
+		return true
+
+	case k >= BB_RESERVED_POSITIVE_DOUBLE && k <= MAX_RESERVED_POSITIVE_DOUBLE:
+		return true
+
+	case k == BB_LEGACY_DATE:
+		return true
+
+	default:
+		return false
+	}
+}
+
+//
+// Decode and return the first value in typed buffer, without returning the remaining buffer,
+// so that the caller can inspect it before consuming it with Decode or SkipValue
+//
+func Peek(b []byte) (interface{}, error) {
+	v, _, err := Decode(b)
+	return v, err
+}
+
+//
+// Decode the value starting at offset off in typed buffer b.
+// Returns decoded value and the offset of the next value.
+// Decoding errors report their offset in b (not relative to off),
+// and ValueOutOfRangeError is returned if off is not within b
+//
+func DecodeAt(b []byte, off int) (interface{}, int, error) {
+	if off < 0 || off > len(b) {
+		return nil, off, ValueOutOfRangeError
+	}
+
+	v, next, err := Decode(b[off:])
+	if err != nil {
+		return nil, off, withOffset(err, off)
+	}
+
+	return v, len(b) - len(next), nil
+}
+
+//
+// Decode a typed buffer containing exactly one value.
+// Returns TrailingBytesError if there are bytes left after the value
+//
+func DecodeOne(b []byte) (interface{}, error) {
+	v, next, err := Decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(next) > 0 {
+		return nil, TrailingBytesError
+	}
+
+	return v, nil
+}
+
+//
+// Decode first value in typed buffer as nil. Returns true if the value was encoded
+// as nil first, false if encoded as nil last, and the remaining buffer
+//
+func DecodeNil(b []byte) (bool, []byte, error) {
+	if len(b) == 0 {
+		return false, nil, EmptyBufferError
+	}
+
+	switch b[0] {
+	case BB_NIL_FIRST:
+		return true, b[1:], nil
+
+	case BB_NIL_LAST:
+		return false, b[1:], nil
+
+	default:
+		return false, nil, CorruptedBufferError
+	}
+}
+
+//
+// Decode first value in typed buffer as a string. Returns decoded string and remaining buffer
+//
+func DecodeString(b []byte) (string, []byte, error) {
+	if len(b) > 0 {
+		if sb, n, ok := bytesValue(b); ok {
+			return string(sb), b[n:], nil
+		}
+	}
+
+	v, next, err := Decode(b)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sb, ok := v.([]byte)
+	if !ok {
+		return "", nil, CorruptedBufferError
+	}
+
+	return string(sb), next, nil
+}
+
+//
+// Decode first value in typed buffer as an int64. Unsigned values are accepted if they fit.
+// Returns ValueOutOfRangeError if the value doesn't fit in an int64 (e.g. a uint64 above math.MaxInt64
+// or a big integer), or CorruptedBufferError if the value is not an integer
+//
+func DecodeInt64(b []byte) (int64, []byte, error) {
+	v, next, err := Decode(b)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	switch t := v.(type) {
+	case int64:
+		return t, next, nil
+
+	case uint64:
+		if t > math.MaxInt64 {
+			return 0, nil, ValueOutOfRangeError
+		}
+		return int64(t), next, nil
+
+	case *big.Int:
+		return 0, nil, ValueOutOfRangeError
+
+	default:
+		return 0, nil, CorruptedBufferError
+	}
+}
+
+//
+// Decode first value in typed buffer as an int, as DecodeInt64.
+// Returns ValueOutOfRangeError if the value doesn't fit in an int on the current platform
+// (i.e. outside of the int32 range on 32-bit platforms)
+//
+func DecodeInt(b []byte) (int, []byte, error) {
+	i, next, err := DecodeInt64(b)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if i < math.MinInt || i > math.MaxInt {
+		return 0, nil, ValueOutOfRangeError
+	}
+
+	return int(i), next, nil
+}
+
+//
+// Decode first value in typed buffer as an integer, signed or unsigned.
+// The returned bool is true if the value was an unsigned integer above math.MaxInt64,
+// in which case the int64 holds the same bits (uint64(i) returns the original value).
+// Returns ValueOutOfRangeError for big integers, or CorruptedBufferError if the value is not an integer
+//
+func DecodeNumber(b []byte) (int64, bool, []byte, error) {
+	v, next, err := Decode(b)
+	if err != nil {
+		return 0, false, nil, err
+	}
+
+	switch t := v.(type) {
+	case int64:
+		return t, false, next, nil
+
+	case uint64:
+		return int64(t), t > math.MaxInt64, next, nil
+
+	case *big.Int:
+		return 0, false, nil, ValueOutOfRangeError
+
+	default:
+		return 0, false, nil, CorruptedBufferError
+	}
+}
+
+//
+// Return the int64 value at the start of b and its encoded length,
+// if it is encoded as a small or compact integer
+//
+func int64Value(b []byte) (int64, int, bool) {
+	k := b[0]
+
+	switch {
+	case k >= MIN_SMALL_POSITIVE && k <= MAX_SMALL_POSITIVE:
+		return int64(k & SMALL_INT_MASK), 1, true
+
+	case k >= MIN_SMALL_NEGATIVE && k <= MAX_SMALL_NEGATIVE:
+		return int64(k&SMALL_INT_MASK) | SMALL_NEG_MASK, 1, true
+
+	case k >= BB_INT_POSITIVE_VALUE && k <= MAX_INT_POSITIVE_VALUE:
+		n := int(k&7) + 1
+		if len(b) <= n {
+			return 0, 0, false
+		}
+		return uncompactInt64(b[1:1+n], true), 1 + n, true
+
+	case k >= BB_INT_NEGATIVE_VALUE && k <= MAX_INT_NEGATIVE_VALUE:
+		n := 8 - int(k&7)
+		if len(b) <= n {
+			return 0, 0, false
+		}
+		return uncompactInt64(b[1:1+n], false), 1 + n, true
+
+	default:
+		return 0, 0, false
+	}
+}
+
+//
+// Return the content of the slice of bytes at the start of b and its encoded length,
+// if it is encoded with a length (not as ordered bytes)
+//
+func bytesValue(b []byte) ([]byte, int, bool) {
+	k := b[0]
+	if k < BB_BYTES || k > BB_BYTES_LEN_4 {
+		return nil, 0, false
+	}
+
+	h := headerLen(k)
+	if len(b) < 1+h {
+		return nil, 0, false
+	}
+
+	n, err := payloadLen(k, b[1:1+h])
+	if err != nil || len(b)-1-h < n {
+		return nil, 0, false
+	}
+
+	return b[1+h : 1+h+n], 1 + h + n, true
+}
+
+//
+// Return the uint64 value at the start of b and its encoded length
+//
+func uint64Value(b []byte) (uint64, int, bool) {
+	k := b[0]
 
+	switch {
 	case k >= MIN_SMALL_UINT && k <= MAX_SMALL_UINT:
-		return uint64(k & SMALL_UINT_MASK), next, nil
+		return uint64(k - BB_UINT), 1, true
 
-	case (k & BB_UINT_MASK) == BB_UINT:
-		n := int(k & 15)
-		if n == 0 || n > 8 || len(next) < n {
-			return nil, nil, CorruptedBufferError
+	case k >= MIN_UINT_VAR && k <= MAX_UINT_VAR:
+		n := int(k - BB_UINT_VAR)
+		if len(b) <= n {
+			return 0, 0, false
 		}
-		return uncompactUint64(next[0:n]), next[n:], nil
+		return uncompactUint64(b[1 : 1+n]), 1 + n, true
 
-	case (k & BB_DATE_MASK) == BB_DATE:
-		n := int(k & 15)
-		if n == 0 || n > 8 || len(next) < n {
-			return nil, nil, CorruptedBufferError
+	default:
+		return 0, 0, false
+	}
+}
+
+//
+// Decode first value in typed buffer into the value pointed to by ptr, which can be
+// *int64, *uint64, *float64, *bool, *string or *[]byte (sharing memory with b, as Decode).
+// Returns the remaining buffer, CorruptedBufferError if the value doesn't match the type of ptr
+// or ValueOutOfRangeError if it doesn't fit.
+// Unlike Decode, integers and bytes are decoded without allocating
+//
+func DecodeInto(b []byte, ptr interface{}) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, EmptyBufferError
+	}
+
+	switch p := ptr.(type) {
+	case *int64:
+		if i, n, ok := int64Value(b); ok {
+			*p = i
+			return b[n:], nil
 		}
 
-		t := uncompactUint64(next[0:n])
-		return time.Unix(int64(t), 0), next[n:], nil
+		i, next, err := DecodeInt64(b)
+		if err != nil {
+			return nil, err
+		}
 
-	case (k & BB_DATE_MASK) == BB_POSITIVE_DATE:
-		n := int(k & 7)
-		if len(next) < n {
-			return nil, nil, CorruptedBufferError
+		*p = i
+		return next, nil
+
+	case *uint64:
+		if u, n, ok := uint64Value(b); ok {
+			*p = u
+			return b[n:], nil
 		}
 
-		t := uncompactInt64(next[0:n], true)
-		return time.Unix(t+DELTA_DATE, 0), next[n:], nil
+	case *bool:
+		switch b[0] {
+		case BB_BOOLEAN_TRUE:
+			*p = true
+			return b[1:], nil
 
-	case (k & BB_DATE_MASK) == BB_NEGATIVE_DATE:
-		n := int(k & 7)
-		if len(next) < n {
-			return nil, nil, CorruptedBufferError
+		case BB_BOOLEAN_FALSE:
+			*p = false
+			return b[1:], nil
 		}
 
-		t := uncompactInt64(next[0:n], false)
-		return time.Unix(t+DELTA_DATE, 0), next[n:], nil
+	case *[]byte:
+		if bb, n, ok := bytesValue(b); ok {
+			*p = bb
+			return b[n:], nil
+		}
+
+	case *string:
+		if bb, n, ok := bytesValue(b); ok {
+			*p = string(bb)
+			return b[n:], nil
+		}
+
+	case *float64:
+		v, next, err := Decode(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if f, ok := v.(float64); ok {
+			*p = f
+			return next, nil
+		}
 
 	default:
-		return nil, nil, CorruptedBufferError
+		return nil, unsupportedType(ptr)
+	}
+
+	// not a value of the expected type: decode it to report the right error
+	v, next, err := Decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p := ptr.(type) {
+	case *[]byte:
+		if bb, ok := v.([]byte); ok { // ordered or descending bytes
+			*p = bb
+			return next, nil
+		}
+
+	case *string:
+		if bb, ok := v.([]byte); ok {
+			*p = string(bb)
+			return next, nil
+		}
+
+	case *bool:
+		if t, ok := v.(bool); ok {
+			*p = t
+			return next, nil
+		}
+
+	case *uint64:
+		if u, ok := v.(uint64); ok {
+			*p = u
+			return next, nil
+		}
+	}
+
+	return nil, CorruptedBufferError
+}
+
+//
+// Skip first value in typed buffer, without decoding it. Returns the remaining buffer
+//
+func SkipValue(b []byte) ([]byte, error) {
+	n, err := ValueLen(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b[n:], nil
+}
+
+//
+// Return the start of typed buffer b covering exactly its first fields values, i.e. the prefix
+// of a composite key to use for a range scan. The prefix shares memory with b, but appending to it
+// doesn't overwrite b.
+// Returns EmptyBufferError if b has less than fields values, or a DecodeError if a value is invalid
+//
+func Prefix(b []byte, fields int) ([]byte, error) {
+	off := 0
+
+	for i := 0; i < fields; i++ {
+		n, err := valueLen(b[off:], 0)
+		if err != nil {
+			return nil, withOffset(newDecodeError(b[off:], err), off)
+		}
+
+		off += n
+	}
+
+	return b[:off:off], nil
+}
+
+//
+// Return the length of the longest common prefix of typed buffers a and b that is made of whole values,
+// so that a[:n] (and b[:n]) is itself a valid typed buffer. Stops at the first invalid value
+//
+func CommonPrefixLen(a, b []byte) int {
+	off := 0
+
+	for off < len(a) && off < len(b) {
+		n, err := valueLen(a[off:], 0)
+		if err != nil || off+n > len(b) || !bytes.Equal(a[off:off+n], b[off:off+n]) {
+			break
+		}
+
+		off += n
+	}
+
+	return off
+}
+
+//
+// Check that typed buffer b is a sequence of well-formed values, without decoding them.
+// Returns nil if the whole buffer is consistent, or a DecodeError for the first invalid value
+//
+func Validate(b []byte) error {
+	for off := 0; off < len(b); {
+		n, err := valueLen(b[off:], 0)
+		if err != nil {
+			return withOffset(newDecodeError(b[off:], err), off)
+		}
+
+		off += n
+	}
+
+	return nil
+}
+
+//
+// Return the number of bytes used by the first value in typed buffer
+// (type tag, length and payload), without decoding it
+//
+func ValueLen(b []byte) (int, error) {
+	return valueLen(b, 0)
+}
+
+//
+// Return the length of the first value in typed buffer, where all bytes are xor'ed with inv
+// (0x00 for regular values, 0xFF for the content of descending values)
+//
+func valueLen(b []byte, inv byte) (int, error) {
+	if len(b) == 0 {
+		return 0, EmptyBufferError
+	}
+
+	k := b[0] ^ inv
+
+	switch k {
+	case BB_DESC:
+		if inv != 0 || len(b) < 2 {
+			return 0, CorruptedBufferError
+		}
+
+		n, err := valueLen(b[1:], 0xFF)
+		if err != nil {
+			return 0, err
+		}
+		return 1 + n, nil
+
+	case BB_BYTES_ORDERED:
+		n, err := orderedLen(b[1:], inv)
+		if err != nil {
+			return 0, err
+		}
+		return 1 + n, nil
+
+	case BB_DATE_UNIT:
+		return timeUnitLen(b, inv)
+
+	case BB_RAT:
+		return ratLen(b, inv)
+	}
+
+	h := headerLen(k)
+	if len(b) < 1+h {
+		return 0, CorruptedBufferError
+	}
+
+	var hb [4]byte
+	for i := 0; i < h; i++ {
+		hb[i] = b[1+i] ^ inv
+	}
+
+	n, err := payloadLen(k, hb[:h])
+	if err != nil {
+		return 0, err
 	}
+
+	n += 1 + h
+	if len(b) < n {
+		return 0, CorruptedBufferError
+	}
+
+	return n, nil
 }
 
 //
@@ -544,27 +2153,169 @@ func Decode(b []byte) (interface{}, []byte, error) {
 // If strings is true, byte arrays are converterd to string
 //
 func DecodeAll(strings bool, b []byte) ([]interface{}, error) {
+	return DecodeAllOptions(b, DecodeOptions{Strings: strings})
+}
+
+//
+// Decode all values in a type buffer, as DecodeAll, but return TooManyValuesError
+// if the buffer contains more than max values. Use this to bound the work done on untrusted input
+//
+func DecodeAllLimit(b []byte, max int) ([]interface{}, error) {
+	return DecodeAllOptions(b, DecodeOptions{MaxValues: max})
+}
+
+//
+// Decode all values in a typed buffer, as DecodeAll, but don't stop at the first invalid value:
+// skip it (according to ValueLen if its length is known, or one byte at a time) until a valid value
+// is found, and continue from there.
+// Returns the decoded values and a DecodeError, with its offset, for each sequence of invalid bytes.
+// Note that values decoded while resynchronizing may be spurious
+//
+func DecodeAllCollect(b []byte) ([]interface{}, []error) {
 	res := make([]interface{}, 0)
+	errs := []error{}
+	off := 0
+	skipping := false
 
-	for {
+	for len(b) > 0 {
+		v, next, err := Decode(b)
+		if err != nil {
+			if !skipping {
+				errs = append(errs, withOffset(err, off))
+				skipping = true
+			}
+
+			n, lerr := ValueLen(b)
+			if lerr != nil {
+				n = 1
+			}
+
+			off += n
+			b = b[n:]
+			continue
+		}
+
+		skipping = false
+		res = append(res, v)
+		off += len(b) - len(next)
+		b = next
+	}
+
+	return res, errs
+}
+
+//
+// Options for DecodeAllOptions.
+// Strings and byte slices share the same encoding: use StringFields instead of Strings
+// to only convert the values that are known to be strings, i.e. in a record mixing strings and binary data
+//
+type DecodeOptions struct {
+	Strings      bool   // convert byte arrays to string
+	StringFields []bool // convert byte arrays to string for the values at the indexes set to true
+	NilValues    bool   // return nil values as NilValue, to preserve their ordering
+	MaxValues    int    // if > 0, return TooManyValuesError if there are more than MaxValues values
+}
+
+//
+// A decoded nil value, that remembers if it was encoded as nil first or nil last.
+// Encoding a NilValue (i.e. with EncodeNils) produces the original encoding
+//
+type NilValue struct {
+	First bool
+}
+
+//
+// Decode all values in a type buffer according to opts. Return an array of decoded values
+//
+func DecodeAllOptions(b []byte, opts DecodeOptions) ([]interface{}, error) {
+	res, err := appendDecoded(make([]interface{}, 0), b, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+//
+// Decode all values in a type buffer, as DecodeAll(false, b), and append them to dst,
+// so that the capacity of dst can be reused across calls. Returns the extended slice,
+// or dst (without the values decoded before the failing one) and an error
+//
+func DecodeAllAppend(dst []interface{}, b []byte) ([]interface{}, error) {
+	return appendDecoded(dst, b, DecodeOptions{})
+}
+
+//
+// Decode all values in a type buffer according to opts and append them to dst
+//
+func appendDecoded(dst []interface{}, b []byte, opts DecodeOptions) ([]interface{}, error) {
+	res := dst
+	off := 0
+
+	for i := 0; ; i++ {
 		v, next, err := Decode(b)
 		if err == EmptyBufferError {
 			return res, nil
 		}
 
 		if err != nil {
-			return nil, err
+			return dst, withOffset(err, off)
+		}
+
+		if opts.MaxValues > 0 && i == opts.MaxValues {
+			return dst, TooManyValuesError
 		}
 
-		if strings {
+		if opts.Strings || (i < len(opts.StringFields) && opts.StringFields[i]) {
 			if sb, ok := v.([]byte); ok {
 				v = string(sb)
 			}
 		}
 
+		if opts.NilValues && v == nil && b[0] != BB_DESC {
+			v = NilValue{First: b[0] == BB_NIL_FIRST}
+		}
+
+		res = append(res, v)
+		off += len(b) - len(next)
+		b = next
+	}
+}
+
+//
+// Decode the first n values in typed buffer. Returns decoded values and remaining buffer.
+// Returns CorruptedBufferError if the buffer contains less than n values, or ValueOutOfRangeError if n is negative
+//
+func DecodeN(b []byte, n int) ([]interface{}, []byte, error) {
+	if n < 0 {
+		return nil, nil, ValueOutOfRangeError
+	}
+
+	// each value takes at least 1 byte, don't trust n for the allocation
+	c := n
+	if c > len(b) {
+		c = len(b)
+	}
+
+	res := make([]interface{}, 0, c)
+	off := 0
+
+	for i := 0; i < n; i++ {
+		v, next, err := Decode(b)
+		if err == EmptyBufferError {
+			return nil, nil, CorruptedBufferError
+		}
+
+		if err != nil {
+			return nil, nil, withOffset(err, off)
+		}
+
 		res = append(res, v)
+		off += len(b) - len(next)
 		b = next
 	}
+
+	return res, b, nil
 }
 
 //
@@ -583,7 +2334,108 @@ func DecodeUintArray(b []byte) ([]uint64, error) {
 			return nil, err
 		}
 
-		res = append(res, v.(uint64))
+		u, ok := v.(uint64)
+		if !ok {
+			return nil, CorruptedBufferError
+		}
+
+		res = append(res, u)
+		b = next
+	}
+}
+
+//
+// Decode all values in a typed buffer as an array of int64 values.
+//
+func DecodeIntArray(b []byte) ([]int64, error) {
+	res := []int64{}
+
+	for {
+		v, next, err := Decode(b)
+		if err == EmptyBufferError {
+			return res, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		i, ok := v.(int64)
+		if !ok {
+			return nil, CorruptedBufferError
+		}
+
+		res = append(res, i)
+		b = next
+	}
+}
+
+//
+// Encode an array of int64 values prefixed by their number, so that the array
+// can be embedded as one field of a larger record (see DecodePackedInt64s)
+//
+func EncodePackedInt64s(xs []int64) []byte {
+	b := AppendUint64(nil, uint64(len(xs)))
+
+	for _, i := range xs {
+		b = AppendInt64(b, i)
+	}
+
+	return b
+}
+
+//
+// Decode an array of int64 values encoded with EncodePackedInt64s.
+// Returns decoded values and remaining buffer
+//
+func DecodePackedInt64s(b []byte) ([]int64, []byte, error) {
+	v, next, err := Decode(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n, ok := v.(uint64)
+	if !ok || n > uint64(len(next)) { // each value takes at least 1 byte
+		return nil, nil, CorruptedBufferError
+	}
+
+	res := make([]int64, 0, n)
+
+	for ; n > 0; n-- {
+		i, rest, err := DecodeInt64(next)
+		if err != nil {
+			return nil, nil, withOffset(err, len(b)-len(next))
+		}
+
+		res = append(res, i)
+		next = rest
+	}
+
+	return res, next, nil
+}
+
+//
+// Decode all values in a typed buffer as an array of strings.
+//
+func DecodeStringArray(b []byte) ([]string, error) {
+	res := []string{}
+
+	for {
+		v, next, err := Decode(b)
+		if err == EmptyBufferError {
+			return res, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		sb, ok := v.([]byte)
+		if !ok {
+			return nil, CorruptedBufferError
+		}
+
+		res = append(res, string(sb))
 		b = next
 	}
 }
@@ -2,6 +2,12 @@ package typedbuffer
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"strings"
 	"testing"
 	"time"
 )
@@ -223,3 +229,1991 @@ func TestCompareNil(t *testing.T) {
 		}
 	}
 }
+
+func TestEncodedLen(t *testing.T) {
+	var arr [100000]byte
+
+	values := []interface{}{
+		nil, true, false,
+		0, 7, -8, 8, -9, 255, 256, -256, -257, 65535, -65536, 1000000, -1000000,
+		int64(1) << 62, int64(-1) << 62, int64(9223372036854775807), int64(-9223372036854775808),
+		uint64(0), uint64(16), uint64(17), uint64(255), uint64(256), uint64(18446744073709551615),
+		[]uint64{1, 100, 100000},
+		"", "hello", string(arr[:60]), string(arr[:61]), string(arr[:316]), string(arr[:317]),
+		arr[:1000], arr[:65852], arr[:70000],
+		time.Now(),
+	}
+
+	for _, v := range values {
+		l, err := EncodedLen(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if el := len(MustEncode(v)); l != el {
+			t.Errorf("%T: expected length %v, got %v", v, el, l)
+		}
+	}
+
+	l, err := EncodedLenAll(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if el := len(MustEncode(values...)); l != el {
+		t.Error("expected total length", el, "got", l)
+	}
+
+	if _, err := EncodedLen(struct{}{}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+}
+
+func TestSkipValue(t *testing.T) {
+	b := MustEncode(1, "hello", 42)
+
+	b, err := SkipValue(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = SkipValue(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, next, err := Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v != int64(42) || len(next) != 0 {
+		t.Error("expected 42, got", v, next)
+	}
+
+	if _, err := SkipValue(next); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+
+	b = MustEncode("hello")
+	if _, err := SkipValue(b[:len(b)-1]); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, err := SkipValue(Bytes1); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestTimeRoundTrip(t *testing.T) {
+	times := []time.Time{
+		time.Date(1900, time.March, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(1969, time.December, 31, 23, 59, 59, 999000000, time.UTC),
+		time.Unix(0, 0),
+		time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Now().Truncate(time.Millisecond),
+		time.Date(2100, time.June, 15, 8, 30, 0, 0, time.FixedZone("PDT", -7*3600)),
+	}
+
+	var prev []byte
+
+	for _, tm := range times {
+		b := EncodeTime(tm)
+
+		v, next, err := Decode(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		d, ok := v.(time.Time)
+		if !ok || !d.Equal(tm) || d.Location() != time.UTC || len(next) != 0 {
+			t.Error("expected", tm, "got", v, next)
+		}
+
+		if prev != nil && bytes.Compare(prev, b) != -1 {
+			t.Error(prev, "should be less than", b)
+		}
+
+		prev = b
+	}
+}
+
+func TestTimeDelta(t *testing.T) {
+	epoch := time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	times := []time.Time{
+		time.Date(1800, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC),
+		epoch.Add(-257 * time.Millisecond),
+		epoch.Add(-256 * time.Millisecond),
+		epoch.Add(-time.Millisecond),
+		epoch,
+		epoch.Add(time.Millisecond),
+		epoch.Add(256 * time.Millisecond),
+		time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2300, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	var prev []byte
+
+	for _, tm := range times {
+		b := MustEncode(tm)
+
+		v, _, err := Decode(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if d, ok := v.(time.Time); !ok || !d.Equal(tm) {
+			t.Error("expected", tm, "got", v)
+		}
+
+		if prev != nil && bytes.Compare(prev, b) != -1 {
+			t.Error(prev, "should be less than", b)
+		}
+
+		prev = b
+	}
+
+	if l := len(EncodeTime(epoch)); l != 2 {
+		t.Error("expected 2 bytes for epoch, got", l)
+	}
+
+	if l := len(EncodeTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))); l >= 9 {
+		t.Error("expected compact encoding, got", l, "bytes")
+	}
+}
+
+func TestLegacyDate(t *testing.T) {
+	tests := []struct {
+		b        []byte
+		expected time.Time
+	}{
+		{[]byte{0x51, 0x3C}, time.Unix(60, 0)},
+		{[]byte{0x54, 0x5E, 0x0B, 0xE1, 0x00}, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{[]byte{0x55, 0x01, 0x00, 0x00, 0x00, 0x00}, time.Unix(1<<32, 0)},
+		{[]byte{0x58, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE, 0xAE, 0x80}, time.Unix(-86400, 0)},
+	}
+
+	for _, tt := range tests {
+		// a legacy date followed by another field
+		b := append(append([]byte{}, tt.b...), EncodeInt64(7)...)
+
+		res, err := DecodeAll(false, b)
+		if err != nil || len(res) != 2 {
+			t.Error(tt.b, "expected date and int, got", res, err)
+			continue
+		}
+
+		if d, ok := res[0].(time.Time); !ok || !d.Equal(tt.expected) {
+			t.Error(tt.b, "expected", tt.expected, "got", res[0])
+		}
+
+		if res[1] != int64(7) {
+			t.Error(tt.b, "expected", 7, "got", res[1])
+		}
+
+		if k, err := TypeOf(b); err != nil || k != Date {
+			t.Error(tt.b, "expected", Date, "got", k, err)
+		}
+
+		if l, err := ValueLen(b); err != nil || l != len(tt.b) {
+			t.Error(tt.b, "expected length", len(tt.b), "got", l, err)
+		}
+	}
+
+	if _, _, err := Decode([]byte{BB_LEGACY_DATE, 0x01}); !errors.Is(err, UnsupportedTagError) {
+		t.Error("expected", UnsupportedTagError, "got", err)
+	}
+
+	if _, _, err := Decode([]byte{0x54, 0x5E, 0x0B}); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestTimeDeltaSeconds(t *testing.T) {
+	epoch := time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		b        []byte
+		expected time.Time
+	}{
+		{[]byte{BB_POSITIVE_DATE, 0x3C}, epoch.Add(time.Minute)},
+		{[]byte{BB_POSITIVE_DATE + 1, 0x0E, 0x10}, epoch.Add(time.Hour)},
+		{[]byte{BB_NEGATIVE_DATE + 7, 0xC4}, epoch.Add(-time.Minute)},
+		{[]byte{BB_POSITIVE_DATE + 3, 0x03, 0xC2, 0x67, 0x00}, epoch.Add(730 * 24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		v, next, err := Decode(tt.b)
+		if err != nil || len(next) != 0 {
+			t.Error(tt.b, "decode error", err, next)
+			continue
+		}
+
+		if d, ok := v.(time.Time); !ok || !d.Equal(tt.expected) {
+			t.Error(tt.b, "expected", tt.expected, "got", v)
+		}
+
+		if b := EncodeTimeDelta(tt.expected); !bytes.Equal(b, tt.b) {
+			t.Error(tt.expected, "expected", tt.b, "got", b)
+		}
+	}
+
+	// sub-second precision is truncated
+	if b := EncodeTimeDelta(epoch.Add(time.Minute + 500*time.Millisecond)); !bytes.Equal(b, tests[0].b) {
+		t.Error("expected", tests[0].b, "got", b)
+	}
+}
+
+func TestDateFixed(t *testing.T) {
+	times := []time.Time{
+		time.Date(1800, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(1969, time.December, 31, 23, 59, 59, 999000000, time.UTC),
+		time.Unix(0, 0),
+		time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Now().Truncate(time.Millisecond),
+		time.Date(2300, time.January, 1, 0, 0, 0, 0, time.FixedZone("PDT", -7*3600)),
+	}
+
+	var prev []byte
+
+	for _, tm := range times {
+		b := EncodeDateFixed(tm)
+		if len(b) != 9 || b[0] != BB_DATE {
+			t.Error(tm, "unexpected encoding", b)
+		}
+
+		v, next, err := Decode(append(b, One...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		d, ok := v.(time.Time)
+		if !ok || !d.Equal(tm) || d.Location() != time.UTC || !bytes.Equal(next, One) {
+			t.Error("expected", tm, "got", v, next)
+		}
+
+		if l, err := ValueLen(b); err != nil || l != 9 {
+			t.Error("expected length 9, got", l, err)
+		}
+
+		if prev != nil && (bytes.Compare(prev, b) != -1 || Compare(prev, b) != -1) {
+			t.Error(prev, "should be less than", b)
+		}
+
+		// fixed and compact dates compare by value
+		if c := Compare(b, EncodeTime(tm)); c != 0 {
+			t.Error(tm, "expected fixed and compact dates to be equal, got", c)
+		}
+
+		prev = b
+	}
+
+	// truncated to milliseconds
+	tm := time.Date(2020, time.May, 1, 12, 0, 0, 123456789, time.UTC)
+	if v, _, _ := Decode(EncodeDateFixed(tm)); !v.(time.Time).Equal(tm.Truncate(time.Millisecond)) {
+		t.Error("expected", tm.Truncate(time.Millisecond), "got", v)
+	}
+
+	if _, _, err := Decode(EncodeDateFixed(tm)[:8]); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	durations := []time.Duration{
+		math.MinInt64,
+		-24 * time.Hour,
+		-time.Second,
+		-1,
+		0,
+		1,
+		time.Millisecond,
+		90 * time.Minute,
+		math.MaxInt64,
+	}
+
+	var prev []byte
+
+	for _, d := range durations {
+		b := EncodeDuration(d)
+
+		if len(b) != 9 || !bytes.Equal(b, MustEncode(d)) {
+			t.Error(d, "unexpected encoding", b)
+		}
+
+		if l, err := EncodedLen(d); err != nil || l != len(b) {
+			t.Error(d, "expected length", len(b), "got", l, err)
+		}
+
+		v, next, err := Decode(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v != d || len(next) != 0 {
+			t.Error("expected", d, "got", v, next)
+		}
+
+		if k, _ := TypeOf(b); k != Duration {
+			t.Error("expected", Duration, "got", k)
+		}
+
+		if prev != nil && (bytes.Compare(prev, b) != -1 || Compare(prev, b) != -1) {
+			t.Error(prev, "should be less than", b)
+		}
+
+		prev = b
+	}
+
+	// a duration is not an integer
+	if bytes.Equal(EncodeDuration(time.Second), MustEncode(int64(time.Second))) {
+		t.Error("duration should not be encoded as an integer")
+	}
+}
+
+func TestEncodeSignedWidths(t *testing.T) {
+	values := []interface{}{
+		int8(-128), int8(5), int8(127),
+		int16(-32768), int16(300), int16(32767),
+		int32(-2147483648), int32(100000), int32(2147483647),
+		'x',
+	}
+
+	expected := []int64{-128, 5, 127, -32768, 300, 32767, -2147483648, 100000, 2147483647, 'x'}
+
+	b, err := Encode(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := MustDecodeAll(b)
+	if len(res) != len(expected) {
+		t.Fatal("expected", expected, "got", res)
+	}
+
+	for i, v := range res {
+		if v != expected[i] {
+			t.Errorf("%T: expected %v, got %v", values[i], expected[i], v)
+		}
+	}
+}
+
+func TestSmallNegativeBoundary(t *testing.T) {
+	tests := []struct {
+		i       int64
+		encoded []byte
+	}{
+		{-257, []byte{0x66, 0xFE, 0xFF}},
+		{-256, []byte{0x67, 0x00}},
+		{-255, []byte{0x67, 0x01}},
+		{-9, []byte{0x67, 0xF7}},
+		{-8, []byte{0x68}},
+		{-7, []byte{0x69}},
+		{-1, []byte{0x6F}},
+		{0, []byte{0xE0}},
+	}
+
+	var prev []byte
+
+	for _, tt := range tests {
+		b := EncodeInt64(tt.i)
+		if !bytes.Equal(b, tt.encoded) {
+			t.Error(tt.i, "expected", tt.encoded, "got", b)
+		}
+
+		if eb := MustEncode(tt.i); !bytes.Equal(eb, b) {
+			t.Error(tt.i, "Encode expected", b, "got", eb)
+		}
+
+		v, next, err := Decode(b)
+		if err != nil || v != tt.i || len(next) != 0 {
+			t.Error(tt.i, "expected", tt.i, "got", v, next, err)
+		}
+
+		if prev != nil && (bytes.Compare(prev, b) != -1 || Compare(prev, b) != -1) {
+			t.Error(prev, "should be less than", b)
+		}
+
+		prev = b
+	}
+
+	if bytes.Compare(EncodeInt64(-9), EncodeInt64(-8)) != -1 {
+		t.Error("-9 should sort before -8")
+	}
+}
+
+func TestEncodeUnsignedWidths(t *testing.T) {
+	values := []interface{}{
+		uint(0), uint(1000000),
+		uint8(16), uint8(17), uint8(255),
+		uint16(300), uint16(65535),
+		uint32(100000), uint32(4294967295),
+		[]byte("x"),
+	}
+
+	expected := []interface{}{
+		uint64(0), uint64(1000000),
+		uint64(16), uint64(17), uint64(255),
+		uint64(300), uint64(65535),
+		uint64(100000), uint64(4294967295),
+		"x",
+	}
+
+	b, err := Encode(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := MustDecodeAll(b)
+	if len(res) != len(expected) {
+		t.Fatal("expected", expected, "got", res)
+	}
+
+	for i, v := range res {
+		if v != expected[i] {
+			t.Errorf("%T: expected %v, got %v", values[i], expected[i], v)
+		}
+	}
+}
+
+func TestDecodeIntArray(t *testing.T) {
+	res, err := DecodeIntArray(MustEncode(-5, 0, 300, -100000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int64{-5, 0, 300, -100000}
+	if len(res) != len(expected) {
+		t.Fatal("expected", expected, "got", res)
+	}
+
+	for i, v := range res {
+		if v != expected[i] {
+			t.Error("expected", expected[i], "got", v)
+		}
+	}
+
+	if _, err := DecodeIntArray(MustEncode(1, "hello")); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestDecodeUintArrayMismatch(t *testing.T) {
+	res, err := DecodeUintArray(MustEncode([]uint64{1, 100, 100000}))
+	if err != nil || len(res) != 3 {
+		t.Error("unexpected result", res, err)
+	}
+
+	if _, err := DecodeUintArray(MustEncode(int64(5))); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestEncodeIntArrays(t *testing.T) {
+	expected := []int64{-100000, -9, -8, 0, 7, 8, 300, 1000000000}
+
+	for _, v := range []interface{}{
+		expected,
+		[]int{-100000, -9, -8, 0, 7, 8, 300, 1000000000},
+	} {
+		b, err := Encode(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if l, _ := EncodedLen(v); l != len(b) {
+			t.Error("expected length", len(b), "got", l)
+		}
+
+		res, err := DecodeIntArray(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(res) != len(expected) {
+			t.Fatal("expected", expected, "got", res)
+		}
+
+		for i, v := range res {
+			if v != expected[i] {
+				t.Error("expected", expected[i], "got", v)
+			}
+		}
+	}
+}
+
+func TestEncodeStringArray(t *testing.T) {
+	expected := []string{"a", "bb", "ccc"}
+
+	res, err := DecodeStringArray(MustEncode(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != len(expected) {
+		t.Fatal("expected", expected, "got", res)
+	}
+
+	for i, v := range res {
+		if v != expected[i] {
+			t.Error("expected", expected[i], "got", v)
+		}
+	}
+
+	if _, err := DecodeStringArray(MustEncode("a", 1)); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestEncodeString(t *testing.T) {
+	b := append(EncodeString("hello"), EncodeString("")...)
+
+	if !bytes.Equal(b, MustEncode("hello", []byte{})) {
+		t.Error("string and bytes encodings should match")
+	}
+
+	s, next, err := DecodeString(b)
+	if err != nil || s != "hello" {
+		t.Error("expected hello, got", s, err)
+	}
+
+	s, next, err = DecodeString(next)
+	if err != nil || s != "" || len(next) != 0 {
+		t.Error("expected empty string, got", s, next, err)
+	}
+
+	if _, _, err := DecodeString(EncodeInt(1)); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestBytesOrdered(t *testing.T) {
+	values := []string{"", "\x00", "\x00\x00", "\x00\x01", "a", "a\x00", "a\x00b", "a\xff", "dog", "dogs", "dogz", "e"}
+
+	var prev []byte
+
+	for _, s := range values {
+		b := EncodeBytesOrdered([]byte(s))
+
+		if prev != nil && bytes.Compare(prev, b) != -1 {
+			t.Errorf("%q should be less than %q", prev, b)
+		}
+
+		v, next, err := Decode(append(b, One...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sb, ok := v.([]byte); !ok || string(sb) != s || !bytes.Equal(next, One) {
+			t.Errorf("expected %q, got %q %v", s, v, next)
+		}
+
+		if next, err := SkipValue(append(b, One...)); err != nil || !bytes.Equal(next, One) {
+			t.Error("skip failed", next, err)
+		}
+
+		prev = b
+	}
+
+	b := EncodeBytesOrdered([]byte("dog"))
+	if _, _, err := Decode(b[:len(b)-1]); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestDecodeNil(t *testing.T) {
+	for _, first := range []bool{true, false} {
+		b := MustEncodeNils(first, nil, 1)
+
+		f, next, err := DecodeNil(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if f != first || !bytes.Equal(next, One) {
+			t.Error("expected", first, One, "got", f, next)
+		}
+
+		if !bytes.Equal(EncodeNil(f), b[:1]) {
+			t.Error("nil should re-encode as", b[:1])
+		}
+	}
+
+	if _, _, err := DecodeNil(One); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := DecodeNil(nil); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	b := MustEncode(10, "hello", -100000)
+
+	_, err := DecodeAll(false, b[:len(b)-1])
+	if !errors.Is(err, CorruptedBufferError) {
+		t.Fatal("expected", CorruptedBufferError, "got", err)
+	}
+
+	t.Log(err)
+
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		t.Fatal("expected DecodeError, got", err)
+	}
+
+	if off := len(MustEncode(10, "hello")); de.Offset != off || de.Tag != b[off] {
+		t.Error("expected offset", off, "tag", b[off], "got", de.Offset, de.Tag)
+	}
+
+	_, err = DecodeAll(false, append(MustEncode(1, 2), 0x01))
+	if !errors.As(err, &de) || de.Offset != 2 || de.Reason != "invalid tag" {
+		t.Error("expected invalid tag at offset 2, got", err)
+	}
+
+	if _, _, err := Decode(nil); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+}
+
+func TestDecodeN(t *testing.T) {
+	b := MustEncode(1, "hello", 42)
+
+	res, next, err := DecodeN(b, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != 2 || res[0] != int64(1) || string(res[1].([]byte)) != "hello" {
+		t.Error("expected [1 hello], got", res)
+	}
+
+	if !bytes.Equal(next, MustEncode(42)) {
+		t.Error("expected", MustEncode(42), "got", next)
+	}
+
+	if _, _, err := DecodeN(b, 4); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if res, next, err := DecodeN(b, 0); err != nil || len(res) != 0 || !bytes.Equal(next, b) {
+		t.Error("expected no values, got", res, next, err)
+	}
+
+	if _, _, err := DecodeN(b, -1); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	// a huge count doesn't preallocate more than the buffer can hold
+	if _, _, err := DecodeN(b, math.MaxInt); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestDecodeOne(t *testing.T) {
+	v, err := DecodeOne(MustEncode(42))
+	if err != nil || v != int64(42) {
+		t.Error("expected 42, got", v, err)
+	}
+
+	if _, err := DecodeOne(MustEncode(42, "hello")); err != TrailingBytesError {
+		t.Error("expected", TrailingBytesError, "got", err)
+	}
+
+	if _, err := DecodeOne(nil); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+}
+
+func TestBytesLengths(t *testing.T) {
+	var arr [70000]byte
+
+	for i := range arr {
+		arr[i] = byte(i)
+	}
+
+	for _, l := range []int{0, 1, 15, 16, 31, 32, 47, 48, 60, 61, 62, 316, 317, 318, 65851, 65852, 65853, 70000} {
+		b := EncodeBytes(arr[:l])
+
+		v, next, err := Decode(append(b, One...))
+		if err != nil {
+			t.Fatal(l, err)
+		}
+
+		if sb, ok := v.([]byte); !ok || !bytes.Equal(sb, arr[:l]) || !bytes.Equal(next, One) {
+			t.Error(l, "bytes: unexpected decoded value")
+		}
+
+		if next, err := SkipValue(append(b, One...)); err != nil || !bytes.Equal(next, One) {
+			t.Error(l, "bytes: unexpected skip", err)
+		}
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	var arr [70000]byte
+
+	values := []interface{}{
+		true, 1000, -1000, int64(-9223372036854775808), uint64(18446744073709551615), time.Now(),
+		"hello", arr[:61], arr[:317], arr[:70000],
+	}
+
+	for _, v := range values {
+		b := MustEncode(v)
+
+		for n := 0; n < len(b); n++ {
+			if _, _, err := Decode(b[:n]); err == nil {
+				t.Errorf("%T: expected error decoding %v of %v bytes", v, n, len(b))
+			}
+
+			if _, err := SkipValue(b[:n]); err == nil {
+				t.Errorf("%T: expected error skipping %v of %v bytes", v, n, len(b))
+			}
+		}
+	}
+
+	// length headers larger than the buffer
+	for _, b := range [][]byte{
+		{BB_BYTES_LEN_1, 0xFF, 1, 2, 3},
+		{BB_BYTES_LEN_2, 0xFF, 0xFF, 1, 2, 3},
+		{BB_BYTES_LEN_4, 0xFF, 0xFF, 0xFF, 0xFF, 1, 2, 3},
+		{BB_BYTES | 60, 1, 2, 3},
+	} {
+		if _, _, err := Decode(b); !errors.Is(err, CorruptedBufferError) {
+			t.Error(b, "expected", CorruptedBufferError, "got", err)
+		}
+	}
+}
+
+func TestDecodeCopy(t *testing.T) {
+	b := MustEncode("hello", 42)
+
+	aliased, _, err := Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	copied, next, err := DecodeCopy(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _, _ := Decode(next); v != int64(42) {
+		t.Error("expected 42, got", v)
+	}
+
+	// reuse the backing buffer
+	copy(b, MustEncode("world"))
+
+	if string(aliased.([]byte)) != "world" {
+		t.Error("Decode should return a slice of the input buffer, got", aliased)
+	}
+
+	if string(copied.([]byte)) != "hello" {
+		t.Error("DecodeCopy should not be affected by changes to the input buffer, got", copied)
+	}
+}
+
+func TestCloneValues(t *testing.T) {
+	b := MustEncode("hello", 42, net.IPv4(10, 0, 0, 1), "world")
+
+	aliased, err := DecodeAll(false, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cloned := CloneValues(aliased)
+
+	// reuse the backing buffer
+	for i := range b {
+		b[i] = 0
+	}
+
+	if string(aliased[0].([]byte)) == "hello" {
+		t.Error("DecodeAll should return slices of the input buffer, got", aliased[0])
+	}
+
+	if string(cloned[0].([]byte)) != "hello" || string(cloned[3].([]byte)) != "world" {
+		t.Error("cloned values should not be affected by changes to the input buffer, got", cloned)
+	}
+
+	if cloned[1] != int64(42) {
+		t.Error("expected 42, got", cloned[1])
+	}
+
+	if ip := cloned[2].(net.IP); !ip.Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Error("expected 10.0.0.1, got", ip)
+	}
+
+	if CloneValues(nil) != nil {
+		t.Error("expected nil")
+	}
+}
+
+func TestValueLen(t *testing.T) {
+	var arr [70000]byte
+
+	values := []interface{}{
+		nil, true, false,
+		0, 7, -8, 8, -9, 1000, -1000, int64(-9223372036854775808), int64(9223372036854775807),
+		uint64(0), uint64(16), uint64(17), uint64(18446744073709551615),
+		"", "hello", arr[:60], arr[:61], arr[:316], arr[:317], arr[:65852], arr[:65853],
+		time.Unix(0, 0), time.Now(),
+		new(big.Int).Lsh(big.NewInt(1), 100), new(big.Int).Lsh(big.NewInt(-1), 100),
+		0.0, 1.5, -1.5, math.Inf(1), math.NaN(),
+	}
+
+	for _, v := range values {
+		b := MustEncode(v)
+
+		l, err := ValueLen(append(b, MustEncode(1, 2)...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if l != len(b) {
+			t.Errorf("%T: expected length %v, got %v", v, len(b), l)
+		}
+
+		d := MustEncodeDesc(v)
+		if l, err := ValueLen(append(d, One...)); err != nil || l != len(d) {
+			t.Errorf("%T: expected desc length %v, got %v %v", v, len(d), l, err)
+		}
+	}
+
+	o := EncodeBytesOrdered([]byte("a\x00b"))
+	if l, err := ValueLen(append(o, One...)); err != nil || l != len(o) {
+		t.Error("expected ordered bytes length", len(o), "got", l, err)
+	}
+
+	if _, err := ValueLen(nil); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+
+	if _, err := ValueLen([]byte{0x01}); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestUintBoundary(t *testing.T) {
+	tests := []struct {
+		u uint64
+		b []byte
+	}{
+		{15, []byte{0x8F}},
+		{16, []byte{0x90}},
+		{17, []byte{0x91, 17}},
+		{255, []byte{0x91, 0xFF}},
+		{256, []byte{0x92, 0x01, 0x00}},
+		{1<<56 - 1, []byte{0x97, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{1 << 56, []byte{0x98, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{math.MaxUint64 - 1, []byte{0x98, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE}},
+		{math.MaxUint64, []byte{0x98, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+	}
+
+	var prev []byte
+
+	for _, tt := range tests {
+		b := EncodeUint64(tt.u)
+		if !bytes.Equal(b, tt.b) {
+			t.Error(tt.u, "expected", tt.b, "got", b)
+		}
+
+		v, next, err := Decode(b)
+		if err != nil || v != tt.u || len(next) != 0 {
+			t.Error("expected", tt.u, "got", v, next, err)
+		}
+
+		if prev != nil && bytes.Compare(prev, b) != -1 {
+			t.Error(prev, "should be less than", b)
+		}
+
+		prev = b
+	}
+
+	// the largest uint sorts above all smaller uints
+	max := EncodeUint64(math.MaxUint64)
+
+	for _, u := range []uint64{0, 16, 17, math.MaxUint32, math.MaxInt64, 1 << 63, math.MaxUint64 - 1} {
+		if b := EncodeUint64(u); bytes.Compare(b, max) != -1 || Compare(b, max) != -1 {
+			t.Error(u, "should be less than", uint64(math.MaxUint64))
+		}
+	}
+
+	// BB_UINT_VAR is the value 16, never a length header
+	v, next, err := Decode([]byte{BB_UINT_VAR, 0xFF})
+	if err != nil || v != uint64(16) || !bytes.Equal(next, []byte{0xFF}) {
+		t.Error("expected 16, got", v, next, err)
+	}
+}
+
+func TestUintInvalidLength(t *testing.T) {
+	// tags after MAX_UINT_VAR would be lengths of 9 to 15 bytes, that the encoder never produces
+	for k := byte(MAX_UINT_VAR + 1); k <= 0x9F; k++ {
+		for _, l := range []int{0, 1, 8, 15, 16} {
+			b := append([]byte{k}, bytes.Repeat([]byte{0xFF}, l)...)
+
+			if _, _, err := Decode(b); !errors.Is(err, CorruptedBufferError) {
+				t.Errorf("%x+%d bytes: expected %v, got %v", k, l, CorruptedBufferError, err)
+			}
+
+			if _, err := ValueLen(b); !errors.Is(err, CorruptedBufferError) {
+				t.Errorf("%x+%d bytes: ValueLen expected %v, got %v", k, l, CorruptedBufferError, err)
+			}
+
+			var u uint64
+			if _, err := DecodeInto(b, &u); !errors.Is(err, CorruptedBufferError) {
+				t.Errorf("%x+%d bytes: DecodeInto expected %v, got %v", k, l, CorruptedBufferError, err)
+			}
+
+			if _, err := NewDecoder(bytes.NewReader(b)).Decode(); !errors.Is(err, CorruptedBufferError) {
+				t.Errorf("%x+%d bytes: Decoder expected %v, got %v", k, l, CorruptedBufferError, err)
+			}
+		}
+	}
+}
+
+func TestEncodeFloat64(t *testing.T) {
+	values := []float64{
+		math.Inf(-1), -math.MaxFloat64, -1000.5, -1, -math.SmallestNonzeroFloat64, math.Copysign(0, -1),
+		0, math.SmallestNonzeroFloat64, 0.5, 1, 1000.5, math.MaxFloat64, math.Inf(1), math.NaN(),
+	}
+
+	var prev []byte
+
+	for _, f := range values {
+		b := MustEncode(f)
+
+		if l, _ := EncodedLen(f); l != len(b) {
+			t.Error(f, "expected length", len(b), "got", l)
+		}
+
+		v, next, err := Decode(b)
+		if err != nil || len(next) != 0 {
+			t.Fatal(f, "unexpected", next, err)
+		}
+
+		df, ok := v.(float64)
+		if !ok {
+			t.Fatalf("%v: expected float64, got %T", f, v)
+		}
+
+		if math.IsNaN(f) {
+			if !math.IsNaN(df) {
+				t.Error("expected NaN, got", df)
+			}
+		} else if df != f || math.Signbit(df) != math.Signbit(f) {
+			t.Error("expected", f, "got", df)
+		}
+
+		if prev != nil && bytes.Compare(prev, b) != -1 {
+			t.Error(prev, "should be less than", b)
+		}
+
+		if prev != nil && Compare(prev, b) != -1 {
+			t.Error("Compare:", prev, "should be less than", b)
+		}
+
+		prev = b
+	}
+
+	if _, _, err := Decode([]byte{BB_DOUBLE_POSITIVE_VALUE, 0x3F}); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestFloat64SpecialValues(t *testing.T) {
+	tests := []struct {
+		f   interface{}
+		tag byte
+	}{
+		{math.Inf(-1), BB_DOUBLE_NEGATIVE_INFINITY},
+		{float32(math.Inf(-1)), BB_DOUBLE_NEGATIVE_INFINITY},
+		{math.Copysign(0, -1), BB_DOUBLE_NEGATIVE_ZERO},
+		{0.0, BB_DOUBLE_POSITIVE_ZERO},
+		{math.Inf(1), BB_DOUBLE_POSITIVE_INFINITY},
+		{float32(math.Inf(1)), BB_DOUBLE_POSITIVE_INFINITY},
+		{math.NaN(), BB_DOUBLE_NAN},
+		{float32(math.NaN()), BB_DOUBLE_NAN},
+	}
+
+	for _, tt := range tests {
+		b := MustEncode(tt.f)
+		if len(b) != 1 || b[0] != tt.tag {
+			t.Errorf("%T(%v): expected [%x], got %x", tt.f, tt.f, tt.tag, b)
+		}
+
+		if l, _ := EncodedLen(tt.f); l != 1 {
+			t.Errorf("%T(%v): expected length 1, got %v", tt.f, tt.f, l)
+		}
+	}
+
+	// -Inf sorts before any other double, and NaN after any other double
+	lowest, highest := EncodeFloat64(math.Inf(-1)), EncodeFloat64(math.NaN())
+
+	for _, f := range []float64{-math.MaxFloat64, -1, 0, 1, math.MaxFloat64, math.Inf(1)} {
+		b := EncodeFloat64(f)
+
+		if bytes.Compare(lowest, b) != -1 || Compare(lowest, b) != -1 {
+			t.Error("-Inf should be less than", f)
+		}
+
+		if bytes.Compare(b, highest) != -1 || Compare(b, highest) != -1 {
+			t.Error(f, "should be less than NaN")
+		}
+	}
+}
+
+func TestBytesTooLong(t *testing.T) {
+	defer func(l int64) { maxBytesLen = l }(maxBytesLen)
+	maxBytesLen = 10
+
+	if b, err := EncodeBytesSafe([]byte("short")); err != nil || !bytes.Equal(b, EncodeBytes([]byte("short"))) {
+		t.Error("unexpected", b, err)
+	}
+
+	long := []byte("this is too long")
+
+	if _, err := EncodeBytesSafe(long); err != BytesTooLongError {
+		t.Error("expected", BytesTooLongError, "got", err)
+	}
+
+	for _, v := range []interface{}{long, string(long), []string{"a", string(long)}} {
+		if _, err := Encode(1, v); err != BytesTooLongError {
+			t.Errorf("%T: expected %v, got %v", v, BytesTooLongError, err)
+		}
+	}
+
+	if _, err := EncodeMap(map[string]interface{}{string(long): 1}); err != BytesTooLongError {
+		t.Error("expected", BytesTooLongError, "got", err)
+	}
+
+	if _, err := EncodeStruct(struct{ S string }{string(long)}); !errors.Is(err, BytesTooLongError) {
+		t.Error("expected", BytesTooLongError, "got", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := [][]byte{
+		nil,
+		MustEncode(1, "hello", true, nil, uint64(1000), time.Now(), 1.5),
+		MustEncodeDesc(-100000, "hello"),
+		EncodeBytesOrdered([]byte("a\x00b")),
+		MustEncode(new(big.Int).Lsh(big.NewInt(1), 100)),
+	}
+
+	for _, b := range valid {
+		if err := Validate(b); err != nil {
+			t.Error(b, "unexpected", err)
+		}
+	}
+
+	b := MustEncode(1, "hello", 1000)
+
+	tests := []struct {
+		b      []byte
+		offset int
+	}{
+		{b[:len(b)-1], 7},
+		{b[:4], 1},
+		{append(MustEncode(1), 0x01), 1},
+		{[]byte{0x01}, 0},
+		{append(MustEncode("a"), BB_DESC), 2},
+	}
+
+	for _, tt := range tests {
+		err := Validate(tt.b)
+
+		var de *DecodeError
+		if !errors.As(err, &de) {
+			t.Error(tt.b, "expected DecodeError, got", err)
+			continue
+		}
+
+		if de.Offset != tt.offset || !errors.Is(err, CorruptedBufferError) {
+			t.Error(tt.b, "expected offset", tt.offset, "got", de.Offset, err)
+		}
+	}
+
+	if n := testing.AllocsPerRun(100, func() { Validate(valid[1]) }); n != 0 {
+		t.Error("expected no allocations, got", n)
+	}
+}
+
+func TestNegativeZero(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+
+	nb := EncodeFloat64(negZero)
+	pb := EncodeFloat64(0)
+
+	if !bytes.Equal(nb, []byte{BB_DOUBLE_NEGATIVE_ZERO}) || !bytes.Equal(pb, []byte{BB_DOUBLE_POSITIVE_ZERO}) {
+		t.Error("unexpected encoding", nb, pb)
+	}
+
+	if v, err := DecodeOne(nb); err != nil || v.(float64) != 0 || !math.Signbit(v.(float64)) {
+		t.Error("expected -0.0, got", v, err)
+	}
+
+	if v, err := DecodeOne(pb); err != nil || v.(float64) != 0 || math.Signbit(v.(float64)) {
+		t.Error("expected +0.0, got", v, err)
+	}
+
+	// -0.0 sorts immediately below +0.0
+	below := EncodeFloat64(-math.SmallestNonzeroFloat64)
+	above := EncodeFloat64(math.SmallestNonzeroFloat64)
+
+	for _, tt := range []CompareItem{{below, nb}, {nb, pb}, {pb, above}} {
+		if bytes.Compare(tt.min, tt.max) != -1 || Compare(tt.min, tt.max) != -1 {
+			t.Error(tt.min, "should be less than", tt.max)
+		}
+	}
+}
+
+func TestEncodePacked(t *testing.T) {
+	values := []interface{}{1, "hello", true, nil, uint64(1000), time.Unix(0, 0), 1.5, []string{"a", "b"}}
+
+	b, err := EncodePacked(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := MustEncode(values...); !bytes.Equal(b, expected) {
+		t.Error("expected", expected, "got", b)
+	}
+
+	if len(b) != cap(b) {
+		t.Error("expected exact allocation, got len", len(b), "cap", cap(b))
+	}
+
+	if _, err := EncodePacked(1, struct{}{}); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+}
+
+var benchRecord = []interface{}{
+	1, "hello, world", uint64(1000), 100000, true, nil, -42, "the quick brown fox jumps over the lazy dog",
+	int64(1) << 40, uint64(1) << 50, "another field", false, 3.14, time.Unix(1500000000, 0), "last",
+}
+
+func BenchmarkEncodeNils(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		EncodeNils(true, benchRecord...)
+	}
+}
+
+func BenchmarkEncodePacked(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		EncodePacked(benchRecord...)
+	}
+}
+
+func TestDecodeAt(t *testing.T) {
+	b := MustEncode(1, "hello", uint64(1000), nil, 100000)
+	expected := []interface{}{int64(1), []byte("hello"), uint64(1000), nil, int64(100000)}
+
+	off := 0
+	for _, e := range expected {
+		v, next, err := DecodeAt(b, off)
+		if err != nil {
+			t.Fatal(off, err)
+		}
+
+		if eb, ok := e.([]byte); ok {
+			if !bytes.Equal(v.([]byte), eb) {
+				t.Error(off, "expected", e, "got", v)
+			}
+		} else if v != e {
+			t.Error(off, "expected", e, "got", v)
+		}
+
+		if l, _ := ValueLen(b[off:]); next != off+l {
+			t.Error("expected next offset", off+l, "got", next)
+		}
+
+		off = next
+	}
+
+	if off != len(b) {
+		t.Error("expected offset", len(b), "got", off)
+	}
+
+	if _, _, err := DecodeAt(b, len(b)); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+
+	if _, _, err := DecodeAt(b, len(b)+1); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	var de *DecodeError
+	if _, _, err := DecodeAt(b[:len(b)-1], 11); !errors.As(err, &de) || de.Offset != 11 {
+		t.Error("expected DecodeError at offset 11, got", err)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	b := MustEncode(1, "x", true)
+
+	p, err := Prefix(b, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(p, MustEncode(1)) {
+		t.Error("expected", MustEncode(1), "got", p)
+	}
+
+	if values, err := DecodeAll(true, p); err != nil || len(values) != 1 || values[0] != int64(1) {
+		t.Error("expected [1], got", values, err)
+	}
+
+	if p, err := Prefix(b, 2); err != nil || !bytes.Equal(p, MustEncode(1, "x")) {
+		t.Error("expected", MustEncode(1, "x"), "got", p, err)
+	}
+
+	if p, err := Prefix(b, 3); err != nil || !bytes.Equal(p, b) {
+		t.Error("expected", b, "got", p, err)
+	}
+
+	if p, err := Prefix(b, 0); err != nil || len(p) != 0 {
+		t.Error("expected empty prefix, got", p, err)
+	}
+
+	// appending to the prefix doesn't change the key
+	p, _ = Prefix(b, 1)
+	_ = append(p, 0xFF)
+
+	if !bytes.Equal(b, MustEncode(1, "x", true)) {
+		t.Error("key changed to", b)
+	}
+
+	if _, err := Prefix(b, 4); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+
+	var de *DecodeError
+	if _, err := Prefix(append(MustEncode(1), 0x01), 2); !errors.As(err, &de) || de.Offset != 1 {
+		t.Error("expected error at offset 1, got", err)
+	}
+}
+
+func TestCommonPrefixLen(t *testing.T) {
+	tests := []struct {
+		a, b     []byte
+		expected []byte
+	}{
+		{MustEncode(1, "dog"), MustEncode(1, "dogs"), MustEncode(1)},
+		{MustEncode(1, "dog", 2), MustEncode(1, "dog", 3), MustEncode(1, "dog")},
+		{MustEncode(1000, "x"), MustEncode(1001, "x"), nil},
+		{MustEncode(1000), MustEncode(1000, "x"), MustEncode(1000)},
+		{MustEncode(1, "a"), MustEncode(1, "a"), MustEncode(1, "a")},
+		{nil, MustEncode(1), nil},
+		{MustEncode(1, "abc"), append(MustEncode(1), 0x13, 'a', 'b'), MustEncode(1)}, // truncated value
+	}
+
+	for _, tt := range tests {
+		n := CommonPrefixLen(tt.a, tt.b)
+		if n != len(tt.expected) || !bytes.Equal(tt.a[:n], tt.expected) {
+			t.Error(tt.a, tt.b, "expected", len(tt.expected), "got", n)
+		}
+
+		if m := CommonPrefixLen(tt.b, tt.a); m != n {
+			t.Error(tt.b, tt.a, "expected", n, "got", m)
+		}
+
+		if err := Validate(tt.a[:n]); err != nil {
+			t.Error(tt.a[:n], err)
+		}
+	}
+
+	// "dog" and "dot" share a raw prefix, but not a whole value
+	a, b := MustEncode(1, "dog"), MustEncode(1, "dot")
+	if n := CommonPrefixLen(a, b); n != 1 {
+		t.Error("expected 1, got", n)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	b := MustEncode(42, "hello")
+	orig := append([]byte{}, b...)
+
+	v, err := Peek(b)
+	if err != nil || v != int64(42) {
+		t.Fatal("expected 42, got", v, err)
+	}
+
+	if !bytes.Equal(b, orig) {
+		t.Error("expected buffer unchanged", orig, "got", b)
+	}
+
+	v, next, err := Decode(b)
+	if err != nil || v != int64(42) {
+		t.Fatal("expected 42, got", v, err)
+	}
+
+	if s, _ := Peek(next); string(s.([]byte)) != "hello" {
+		t.Error("expected hello, got", s)
+	}
+
+	if _, err := Peek(nil); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+}
+
+func TestDecodeInt64(t *testing.T) {
+	b := MustEncode(-42, uint64(1000), uint64(math.MaxInt64))
+
+	for _, expected := range []int64{-42, 1000, math.MaxInt64} {
+		i, next, err := DecodeInt64(b)
+		if err != nil || i != expected {
+			t.Error("expected", expected, "got", i, err)
+		}
+
+		b = next
+	}
+
+	if _, _, err := DecodeInt64(EncodeUint64(math.MaxUint64)); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	if _, _, err := DecodeInt64(MustEncode(new(big.Int).Lsh(big.NewInt(1), 64))); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	if _, _, err := DecodeInt64(EncodeString("1")); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestDecodeInt(t *testing.T) {
+	b := MustEncode(-42, uint64(1000), math.MinInt, math.MaxInt)
+
+	for _, expected := range []int{-42, 1000, math.MinInt, math.MaxInt} {
+		i, next, err := DecodeInt(b)
+		if err != nil || i != expected {
+			t.Error("expected", expected, "got", i, err)
+		}
+
+		b = next
+	}
+
+	if _, _, err := DecodeInt(EncodeUint64(math.MaxUint64)); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	if _, _, err := DecodeInt(EncodeString("1")); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestAppendInt64(t *testing.T) {
+	prefix := EncodeString("key")
+
+	for _, i := range []int64{0, -1, 7, -8, 8, -9, 1000, -1000, math.MinInt64, math.MaxInt64} {
+		b := AppendInt64(append([]byte{}, prefix...), i)
+		if expected := append(append([]byte{}, prefix...), EncodeInt64(i)...); !bytes.Equal(b, expected) {
+			t.Error(i, "expected", expected, "got", b)
+		}
+	}
+
+	buf := make([]byte, 0, 9)
+	if n := testing.AllocsPerRun(100, func() { AppendInt64(buf[:0], math.MaxInt64) }); n != 0 {
+		t.Error("expected no allocations, got", n)
+	}
+}
+
+func BenchmarkAppendInt64(b *testing.B) {
+	buf := make([]byte, 0, 9*100)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		buf = buf[:0]
+		for j := int64(0); j < 100; j++ {
+			buf = AppendInt64(buf, j*1000)
+		}
+	}
+}
+
+func TestAppendHelpers(t *testing.T) {
+	prefix := EncodeString("key")
+
+	appended := func(enc []byte) []byte {
+		return append(append([]byte{}, prefix...), enc...)
+	}
+
+	dst := func() []byte {
+		return append([]byte{}, prefix...)
+	}
+
+	for _, u := range []uint64{0, 16, 17, 1000, math.MaxUint64} {
+		if b := AppendUint64(dst(), u); !bytes.Equal(b, appended(EncodeUint64(u))) {
+			t.Error(u, "expected", appended(EncodeUint64(u)), "got", b)
+		}
+	}
+
+	for _, l := range []int{0, 60, 61, 1000} {
+		bb := make([]byte, l)
+		if b := AppendBytes(dst(), bb); !bytes.Equal(b, appended(EncodeBytes(bb))) {
+			t.Error(l, "expected", appended(EncodeBytes(bb)), "got", b)
+		}
+	}
+
+	for _, v := range []bool{true, false} {
+		if b := AppendBool(dst(), v); !bytes.Equal(b, appended(EncodeBool(v))) {
+			t.Error(v, "expected", appended(EncodeBool(v)), "got", b)
+		}
+
+		if b := AppendNil(dst(), v); !bytes.Equal(b, appended(EncodeNil(v))) {
+			t.Error(v, "expected", appended(EncodeNil(v)), "got", b)
+		}
+	}
+
+	if !bytes.Equal(EncodeBool(true), True) || !bytes.Equal(EncodeNil(false), NilLast) {
+		t.Error("unexpected encoding", EncodeBool(true), EncodeNil(false))
+	}
+
+	// a composite key with a single allocation
+	b := make([]byte, 0, 32)
+	b = AppendUint64(b, 42)
+	b = AppendBytes(b, []byte("name"))
+	b = AppendBool(b, true)
+	b = AppendNil(b, false)
+
+	if expected := MustEncodeNils(false, uint64(42), []byte("name"), true, nil); !bytes.Equal(b, expected) {
+		t.Error("expected", expected, "got", b)
+	}
+}
+
+func TestUnsupportedType(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		name string
+	}{
+		{[]int32{1}, "[]int32"},
+		{[]float64{1.5}, "[]float64"},
+		{struct{}{}, "struct {}"},
+		{map[string]int{}, "map[string]int"},
+	}
+
+	for _, tt := range tests {
+		_, err := Encode(1, tt.v)
+		if !errors.Is(err, NoEncoding) || !strings.Contains(err.Error(), "unsupported type "+tt.name) {
+			t.Error("expected unsupported type", tt.name, "got", err)
+		}
+
+		if _, err := EncodedLen(tt.v); !errors.Is(err, NoEncoding) || !strings.Contains(err.Error(), tt.name) {
+			t.Error("expected unsupported type", tt.name, "got", err)
+		}
+	}
+
+	_, err := EncodeStruct(struct{ Tags []string }{})
+	if !errors.Is(err, NoEncoding) || !strings.Contains(err.Error(), "Tags") || !strings.Contains(err.Error(), "[]string") {
+		t.Error("expected unsupported type []string for field Tags, got", err)
+	}
+}
+
+func TestDecodeAllOptions(t *testing.T) {
+	b := MustEncodeNils(false, 1, nil, "x", nil)
+
+	values, err := DecodeAllOptions(b, DecodeOptions{Strings: true, NilValues: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []interface{}{int64(1), NilValue{First: false}, "x", NilValue{First: false}}
+	for i, v := range values {
+		if v != expected[i] {
+			t.Error("expected", expected[i], "got", v)
+		}
+	}
+
+	// re-encoding with the default nil ordering reproduces the original bytes
+	if eb := MustEncode(values...); !bytes.Equal(eb, b) {
+		t.Error("expected", b, "got", eb)
+	}
+
+	if l, _ := EncodedLenAll(values...); l != len(b) {
+		t.Error("expected length", len(b), "got", l)
+	}
+
+	// without NilValues nils are lost
+	values, _ = DecodeAllOptions(b, DecodeOptions{})
+	if values[1] != nil {
+		t.Error("expected nil, got", values[1])
+	}
+
+	if eb := MustEncode(values...); bytes.Equal(eb, b) {
+		t.Error("expected different encoding, got", eb)
+	}
+}
+
+func TestDecodeAllStringFields(t *testing.T) {
+	blob := []byte{0x00, 0xFF, 'a'}
+	b := MustEncode("name", blob, 42, "label")
+
+	values, err := DecodeAllOptions(b, DecodeOptions{StringFields: []bool{true, false, true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values[0] != "name" {
+		t.Errorf("expected string name, got %T(%v)", values[0], values[0])
+	}
+
+	if vb, ok := values[1].([]byte); !ok || !bytes.Equal(vb, blob) {
+		t.Errorf("expected bytes %v, got %T(%v)", blob, values[1], values[1])
+	}
+
+	// StringFields doesn't apply to values that are not byte arrays
+	if values[2] != int64(42) {
+		t.Error("expected 42, got", values[2])
+	}
+
+	// values after the end of StringFields are not converted
+	if vb, ok := values[3].([]byte); !ok || string(vb) != "label" {
+		t.Errorf("expected bytes label, got %T(%v)", values[3], values[3])
+	}
+
+	// Strings converts all byte arrays
+	values, _ = DecodeAllOptions(b, DecodeOptions{Strings: true})
+	if values[1] != string(blob) || values[3] != "label" {
+		t.Error("expected all strings, got", values)
+	}
+}
+
+func TestDecodeAllCollect(t *testing.T) {
+	// a corrupted value in the middle, and a truncated value at the end
+	b := MustEncode(1, "hello")
+	b = append(b, 0x01, 0x02)
+	b = append(b, MustEncode(true, 3)...)
+	b = append(b, BB_DATE, 0x01)
+
+	values, errs := DecodeAllCollect(b)
+
+	expected := []interface{}{int64(1), "hello", true, int64(3)}
+	if len(values) != len(expected) {
+		t.Fatal("expected", expected, "got", values)
+	}
+
+	for i, v := range values {
+		if sb, ok := v.([]byte); ok {
+			v = string(sb)
+		}
+
+		if v != expected[i] {
+			t.Error("expected", expected[i], "got", v)
+		}
+	}
+
+	if len(errs) != 2 {
+		t.Fatal("expected 2 errors, got", errs)
+	}
+
+	var de *DecodeError
+
+	if !errors.As(errs[0], &de) || de.Offset != 7 || !errors.Is(errs[0], CorruptedBufferError) {
+		t.Error("expected error at offset 7, got", errs[0])
+	}
+
+	if !errors.As(errs[1], &de) || de.Offset != 11 || de.Tag != BB_DATE {
+		t.Error("expected error at offset 11, got", errs[1])
+	}
+
+	// a valid buffer has no errors
+	values, errs = DecodeAllCollect(MustEncode(1, 2, 3))
+	if len(values) != 3 || len(errs) != 0 {
+		t.Error("expected 3 values and no errors, got", values, errs)
+	}
+}
+
+func TestDecodeAllAppend(t *testing.T) {
+	dst := []interface{}{"x"}
+
+	dst, err := DecodeAllAppend(dst, MustEncode(1, true))
+	if err != nil || len(dst) != 3 || dst[0] != "x" || dst[1] != int64(1) || dst[2] != true {
+		t.Fatal("expected [x 1 true], got", dst, err)
+	}
+
+	// reuse the capacity
+	buf := make([]interface{}, 0, 10)
+
+	res, err := DecodeAllAppend(buf, MustEncode(1, 2, 3))
+	if err != nil || len(res) != 3 || &res[0] != &buf[:1][0] {
+		t.Error("expected values appended to buf, got", res, err)
+	}
+
+	res, err = DecodeAllAppend(res[:0], MustEncode(4))
+	if err != nil || len(res) != 1 || res[0] != int64(4) {
+		t.Error("expected [4], got", res, err)
+	}
+
+	// on error, dst is returned unchanged
+	res, err = DecodeAllAppend(dst, append(MustEncode(5), 0x01))
+	if !errors.Is(err, CorruptedBufferError) || len(res) != len(dst) {
+		t.Error("expected", CorruptedBufferError, "and", dst, "got", res, err)
+	}
+}
+
+func TestDecodeAllLimit(t *testing.T) {
+	var b []byte
+	for i := 0; i < 1000; i++ {
+		b = AppendInt64(b, int64(i%5))
+	}
+
+	if values, err := DecodeAllLimit(b, 10); err != TooManyValuesError || values != nil {
+		t.Error("expected", TooManyValuesError, "got", values, err)
+	}
+
+	values, err := DecodeAllLimit(b, 1000)
+	if err != nil || len(values) != 1000 {
+		t.Error("expected 1000 values, got", len(values), err)
+	}
+
+	if _, err := DecodeAllLimit(b[:10], 10); err != nil {
+		t.Error("expected no error for exactly 10 values, got", err)
+	}
+
+	// errors before the limit are still reported
+	if _, err := DecodeAllLimit(append(MustEncode(1, 2), 0x01), 10); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestUnsupportedTag(t *testing.T) {
+	for _, k := range []byte{0x73, 0x77, 0xF4, 0xF7} {
+		b := []byte{k, 0x00, 0x00}
+
+		_, _, err := Decode(b)
+
+		var de *DecodeError
+		if !errors.Is(err, UnsupportedTagError) || errors.Is(err, CorruptedBufferError) || !errors.As(err, &de) {
+			t.Errorf("%02x: expected %v, got %v", k, UnsupportedTagError, err)
+		} else if de.Reason != "reserved tag" {
+			t.Errorf("%02x: expected reserved tag, got %v", k, de.Reason)
+		}
+
+		if _, err := ValueLen(b); err != UnsupportedTagError {
+			t.Errorf("%02x: expected %v, got %v", k, UnsupportedTagError, err)
+		}
+
+		if err := Validate(append(MustEncode(1), b...)); !errors.Is(err, UnsupportedTagError) {
+			t.Errorf("%02x: expected %v, got %v", k, UnsupportedTagError, err)
+		}
+	}
+
+	// structurally invalid tags are still corrupted
+	for _, k := range []byte{0x01, 0x0A, 0x99, 0xFD} {
+		if _, _, err := Decode([]byte{k}); !errors.Is(err, CorruptedBufferError) {
+			t.Errorf("%02x: expected %v, got %v", k, CorruptedBufferError, err)
+		}
+	}
+}
+
+func TestPackedInt64s(t *testing.T) {
+	xs := []int64{1, -1, 1000, math.MinInt64, math.MaxInt64}
+
+	b := EncodeString("before")
+	b = append(b, EncodePackedInt64s(xs)...)
+	b = append(b, EncodePackedInt64s(nil)...)
+	b = append(b, MustEncode(true)...)
+
+	s, next, err := DecodeString(b)
+	if err != nil || s != "before" {
+		t.Fatal("expected before, got", s, err)
+	}
+
+	d, next, err := DecodePackedInt64s(next)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d) != len(xs) {
+		t.Fatal("expected", xs, "got", d)
+	}
+
+	for i := range xs {
+		if d[i] != xs[i] {
+			t.Error("expected", xs[i], "got", d[i])
+		}
+	}
+
+	d, next, err = DecodePackedInt64s(next)
+	if err != nil || len(d) != 0 {
+		t.Error("expected empty array, got", d, err)
+	}
+
+	if v, err := DecodeOne(next); err != nil || v != true {
+		t.Error("expected true, got", v, err)
+	}
+
+	if _, _, err := DecodePackedInt64s(MustEncode(uint64(2), 1, "x")); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	if _, _, err := DecodePackedInt64s(MustEncode(uint64(3), 1, 2)); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	b := MustEncode(-100000, uint64(1000), 1.5, true, "hello", []byte{0, 1})
+	b = append(b, MustEncodeDesc("desc", false)...)
+
+	var i int64
+	var u uint64
+	var f float64
+	var v, dv bool
+	var s, ds string
+	var bb []byte
+
+	var err error
+	for _, ptr := range []interface{}{&i, &u, &f, &v, &s, &bb, &ds, &dv} {
+		if b, err = DecodeInto(b, ptr); err != nil {
+			t.Fatalf("%T: %v", ptr, err)
+		}
+	}
+
+	if len(b) != 0 {
+		t.Error("expected empty buffer, got", b)
+	}
+
+	if i != -100000 || u != 1000 || f != 1.5 || !v || s != "hello" || !bytes.Equal(bb, []byte{0, 1}) || ds != "desc" || dv {
+		t.Error("unexpected", i, u, f, v, s, bb, ds, dv)
+	}
+
+	// unsigned values are accepted for int64 if they fit
+	if _, err := DecodeInto(EncodeUint64(42), &i); err != nil || i != 42 {
+		t.Error("expected 42, got", i, err)
+	}
+
+	if _, err := DecodeInto(EncodeUint64(math.MaxUint64), &i); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	mismatches := []struct {
+		b   []byte
+		ptr interface{}
+	}{
+		{EncodeString("1"), &i},
+		{EncodeInt64(1), &u},
+		{EncodeInt64(1), &f},
+		{EncodeInt64(1), &v},
+		{EncodeInt64(1), &s},
+		{EncodeBool(true), &bb},
+	}
+
+	for _, tt := range mismatches {
+		if _, err := DecodeInto(tt.b, tt.ptr); err != CorruptedBufferError {
+			t.Errorf("%T: expected %v, got %v", tt.ptr, CorruptedBufferError, err)
+		}
+	}
+
+	var i32 int32
+	if _, err := DecodeInto(EncodeInt64(1), &i32); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+
+	if _, err := DecodeInto(nil, &i); err != EmptyBufferError {
+		t.Error("expected", EmptyBufferError, "got", err)
+	}
+
+	if _, err := DecodeInto(EncodeInt64(100000)[:2], &i); !errors.Is(err, CorruptedBufferError) {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+
+	enc := MustEncode(100000)
+	if n := testing.AllocsPerRun(100, func() { DecodeInto(enc, &i) }); n != 0 {
+		t.Error("expected no allocations, got", n)
+	}
+}
+
+func TestDecodeNumber(t *testing.T) {
+	tests := []struct {
+		b        []byte
+		i        int64
+		overflow bool
+	}{
+		{EncodeInt64(-42), -42, false},
+		{EncodeInt64(math.MaxInt64), math.MaxInt64, false},
+		{EncodeUint64(1000), 1000, false},
+		{EncodeUint64(math.MaxInt64), math.MaxInt64, false},
+		{EncodeUint64(math.MaxInt64 + 1), math.MinInt64, true},
+		{EncodeUint64(math.MaxUint64), -1, true},
+	}
+
+	for _, tt := range tests {
+		i, overflow, next, err := DecodeNumber(append(tt.b, One...))
+		if err != nil || i != tt.i || overflow != tt.overflow || !bytes.Equal(next, One) {
+			t.Error(tt.b, "expected", tt.i, tt.overflow, "got", i, overflow, next, err)
+		}
+	}
+
+	if i, _, _, _ := DecodeNumber(EncodeUint64(math.MaxUint64)); uint64(i) != math.MaxUint64 {
+		t.Error("expected", uint64(math.MaxUint64), "got", uint64(i))
+	}
+
+	if _, _, _, err := DecodeNumber(MustEncode(new(big.Int).Lsh(big.NewInt(1), 64))); err != ValueOutOfRangeError {
+		t.Error("expected", ValueOutOfRangeError, "got", err)
+	}
+
+	if _, _, _, err := DecodeNumber(EncodeString("1")); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
+
+func TestSingleByte(t *testing.T) {
+	values := []interface{}{nil, true, false, 0, 7, -8, -1, int64(7), int64(-8), uint64(0), uint64(16)}
+
+	for _, v := range values {
+		for _, nilFirst := range []bool{true, false} {
+			c, ok := singleByte(v, nilFirst)
+			if !ok {
+				t.Errorf("%T %v: expected single byte encoding", v, v)
+				continue
+			}
+
+			var expected []byte
+			switch t := v.(type) {
+			case nil:
+				expected = EncodeNil(nilFirst)
+			case bool:
+				expected = EncodeBool(t)
+			case int:
+				expected = EncodeInt64(int64(t))
+			case int64:
+				expected = EncodeInt64(t)
+			case uint64:
+				expected = EncodeUint64(t)
+			}
+
+			if !bytes.Equal([]byte{c}, expected) {
+				t.Errorf("%T %v: expected %v, got %v", v, v, expected, c)
+			}
+		}
+	}
+
+	for _, v := range []interface{}{8, -9, int64(8), uint64(17), "", 1.0} {
+		if _, ok := singleByte(v, true); ok {
+			t.Errorf("%T %v: unexpected single byte encoding", v, v)
+		}
+	}
+}
+
+// go test -bench EncodeSingleByte -count 5
+//
+//	before: 45.9 ns/op  8 B/op  1 allocs/op
+//	after:  16.8 ns/op  1 B/op  1 allocs/op
+func BenchmarkEncodeSingleByte(b *testing.B) {
+	values := []interface{}{1, true, nil, uint64(3), -2, false, 0, uint64(16)}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		Encode(values[i%len(values)])
+	}
+}
+
+//
+// Return a buffer of 1000 short byte values, and the same values as ordered bytes
+//
+func shortBytesBuffers() ([]byte, []byte) {
+	var b, ob []byte
+
+	for i := 0; i < 1000; i++ {
+		v := []byte(fmt.Sprintf("value-%d\x00%d", i, i*i))
+
+		b = AppendBytes(b, v)
+		ob = append(ob, EncodeBytesOrdered(v)...)
+	}
+
+	return b, ob
+}
+
+// go test -bench 'Decode(Bytes|Copy|String|Ordered)$' -count 5
+// (decoding 1000 short values, the aliased []byte returned by Decode is boxed in an interface{})
+//
+//	                before                          after
+//	DecodeBytes:    24008 B/op  1000 allocs/op      24008 B/op  1000 allocs/op
+//	DecodeCopy:     64017 B/op  3000 allocs/op      40011 B/op  2000 allocs/op
+//	DecodeString:   40012 B/op  2000 allocs/op      16007 B/op  1000 allocs/op
+//	DecodeOrdered:  45481 B/op  2000 allocs/op      45481 B/op  2000 allocs/op
+func BenchmarkDecodeBytes(b *testing.B) {
+	buf, _ := shortBytesBuffers()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		for next := buf; len(next) > 0; {
+			_, next, _ = Decode(next)
+		}
+	}
+}
+
+func BenchmarkDecodeCopy(b *testing.B) {
+	buf, _ := shortBytesBuffers()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		for next := buf; len(next) > 0; {
+			_, next, _ = DecodeCopy(next)
+		}
+	}
+}
+
+func BenchmarkDecodeString(b *testing.B) {
+	buf, _ := shortBytesBuffers()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		for next := buf; len(next) > 0; {
+			_, next, _ = DecodeString(next)
+		}
+	}
+}
+
+func BenchmarkDecodeOrdered(b *testing.B) {
+	_, buf := shortBytesBuffers()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		for next := buf; len(next) > 0; {
+			_, next, _ = Decode(next)
+		}
+	}
+}
+
+// go test -bench 'DecodeAll(New|Append)$' -count 5
+//
+//	DecodeAll:        568 B/op  10 allocs/op
+//	DecodeAllAppend:   72 B/op   5 allocs/op (reusing dst)
+func BenchmarkDecodeAllNew(b *testing.B) {
+	buf := MustEncode(1, "hello", true, 1000, nil, uint64(20), -5, "world", 1.5, false)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		DecodeAll(false, buf)
+	}
+}
+
+func BenchmarkDecodeAllAppend(b *testing.B) {
+	buf := MustEncode(1, "hello", true, 1000, nil, uint64(20), -5, "world", 1.5, false)
+	var dst []interface{}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		dst, _ = DecodeAllAppend(dst[:0], buf)
+	}
+}
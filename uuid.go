@@ -0,0 +1,34 @@
+package typedbuffer
+
+//
+// Encode UUID as 16 bytes with a dedicated tag, so that it can be told apart from a slice of bytes.
+// UUIDs sort in the same order as their canonical string representation
+//
+func EncodeUUID(u [16]byte) []byte {
+	return AppendUUID(make([]byte, 0, 17), u)
+}
+
+//
+// Append encoded UUID to dst
+//
+func AppendUUID(dst []byte, u [16]byte) []byte {
+	return append(append(dst, BB_UUID), u[:]...)
+}
+
+//
+// Decode first value in typed buffer as a UUID. Returns decoded UUID and remaining buffer,
+// or CorruptedBufferError if the value is not a UUID
+//
+func DecodeUUID(b []byte) ([16]byte, []byte, error) {
+	v, next, err := Decode(b)
+	if err != nil {
+		return [16]byte{}, nil, err
+	}
+
+	u, ok := v.([16]byte)
+	if !ok {
+		return [16]byte{}, nil, CorruptedBufferError
+	}
+
+	return u, next, nil
+}
@@ -0,0 +1,63 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func parseUUID(s string) [16]byte {
+	var u [16]byte
+
+	if _, err := hex.Decode(u[:], []byte(strings.ReplaceAll(s, "-", ""))); err != nil {
+		panic(err)
+	}
+
+	return u
+}
+
+func TestEncodeUUID(t *testing.T) {
+	uuids := []string{
+		"00000000-0000-0000-0000-000000000000",
+		"0f8fad5b-d9cb-469f-a165-70867728950e",
+		"7c9e6679-7425-40de-944b-e07fc1f90ae7",
+		"7c9e6679-7425-40de-944b-e07fc1f90ae8",
+		"ffffffff-ffff-ffff-ffff-ffffffffffff",
+	}
+
+	var prev []byte
+
+	for _, s := range uuids {
+		u := parseUUID(s)
+		b := EncodeUUID(u)
+
+		if len(b) != 17 || !bytes.Equal(b, MustEncode(u)) {
+			t.Error(s, "unexpected encoding", b)
+		}
+
+		if prev != nil && (bytes.Compare(prev, b) != -1 || Compare(prev, b) != -1) {
+			t.Error(prev, "should be less than", b)
+		}
+
+		d, next, err := DecodeUUID(append(b, One...))
+		if err != nil || d != u || !bytes.Equal(next, One) {
+			t.Error(s, "expected", u, "got", d, next, err)
+		}
+
+		if k, _ := TypeOf(b); k != UUID {
+			t.Error("expected", UUID, "got", k)
+		}
+
+		if dump := Dump(b); dump != "uuid("+s+")" {
+			t.Error("expected uuid("+s+") got", dump)
+		}
+
+		prev = b
+	}
+
+	u := parseUUID(uuids[1])
+	if _, _, err := DecodeUUID(EncodeBytes(u[:])); err != CorruptedBufferError {
+		t.Error("expected", CorruptedBufferError, "got", err)
+	}
+}
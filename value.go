@@ -0,0 +1,30 @@
+package typedbuffer
+
+//
+// A Value wraps a single value, implementing encoding.BinaryMarshaler and encoding.BinaryUnmarshaler
+//
+type Value struct {
+	V interface{}
+}
+
+//
+// Encode the wrapped value
+//
+func (v Value) MarshalBinary() ([]byte, error) {
+	return Encode(v.V)
+}
+
+//
+// Decode a buffer containing exactly one value into the wrapped value
+// (values referencing data, such as byte slices and IP addresses, are copied as in DecodeCopy,
+// since data may be reused by the caller)
+//
+func (v *Value) UnmarshalBinary(data []byte) error {
+	d, err := DecodeOne(data)
+	if err != nil {
+		return err
+	}
+
+	v.V = cloneValue(d)
+	return nil
+}
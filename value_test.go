@@ -0,0 +1,82 @@
+package typedbuffer
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = Value{}
+	_ encoding.BinaryUnmarshaler = &Value{}
+)
+
+func TestValue(t *testing.T) {
+	now := time.Now().Truncate(time.Millisecond)
+
+	values := []interface{}{
+		nil, true, false,
+		int64(0), int64(-8), int64(1000), int64(-100000),
+		uint64(0), uint64(17), uint64(18446744073709551615),
+		[]byte{}, []byte("hello"),
+		now,
+	}
+
+	for _, v := range values {
+		data, err := Value{v}.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var d Value
+		if err := d.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+
+		switch tv := v.(type) {
+		case []byte:
+			if db, ok := d.V.([]byte); !ok || !bytes.Equal(db, tv) {
+				t.Error("expected", v, "got", d.V)
+			}
+
+		case time.Time:
+			if dt, ok := d.V.(time.Time); !ok || !dt.Equal(tv) {
+				t.Error("expected", v, "got", d.V)
+			}
+
+		default:
+			if d.V != v {
+				t.Error("expected", v, "got", d.V)
+			}
+		}
+	}
+
+	data, _ := Value{"hello"}.MarshalBinary()
+
+	var d Value
+	d.UnmarshalBinary(data)
+	data[1] = 'j'
+
+	if string(d.V.([]byte)) != "hello" {
+		t.Error("decoded value should not alias the input buffer")
+	}
+
+	data, _ = Value{net.IPv4(10, 0, 0, 1)}.MarshalBinary()
+	d.UnmarshalBinary(data)
+	data[len(data)-1] = 2
+
+	if ip := d.V.(net.IP); !ip.Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Error("decoded IP should not alias the input buffer, got", ip)
+	}
+
+	if err := d.UnmarshalBinary(MustEncode(1, 2)); err != TrailingBytesError {
+		t.Error("expected", TrailingBytesError, "got", err)
+	}
+
+	if _, err := (Value{struct{}{}}).MarshalBinary(); !errors.Is(err, NoEncoding) {
+		t.Error("expected", NoEncoding, "got", err)
+	}
+}